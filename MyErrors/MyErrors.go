@@ -1,7 +1,113 @@
 package MyErrors
 
-type Error string
+import (
+	"fmt"
+	"strings"
+)
 
-func (e Error) Error() string { return string(e) }
+// Code is the machine-readable kind of error that is sent to the client.
+type Code string
 
-const DoesNotExist = Error("object with this id does not exist")
+const (
+	InvalidRequest   Code = "invalid_request"
+	Unauthorized     Code = "unauthorized"
+	Forbidden        Code = "forbidden"
+	NotFound         Code = "not_found"
+	ValidationFailed Code = "validation_failed"
+	Conflict         Code = "conflict"
+	Internal         Code = "internal"
+	Timeout          Code = "timeout"
+	Unavailable      Code = "unavailable"
+)
+
+// Error is an error that the client can handle by its code.
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+var (
+	DoesNotExist  = New(NotFound, "object with this id does not exist")
+	InvalidCursor = New(InvalidRequest, "invalid cursor")
+	LastHobby     = New(Conflict, "the user must have at least one hobby")
+	FlagNotLoaded = New(NotFound, "the flag of the country is not loaded yet")
+	ShuttingDown  = New(Unavailable, "the service is shutting down")
+)
+
+// ValidationError names every reference of the input to an object that does not exist
+// and every value that can not be stored.
+type ValidationError struct {
+	CountryId *int     // the country_id that does not exist
+	Hobbies   []int    // the hobby ids that do not exist
+	NoHobbies bool     // the list of hobbies is empty
+	Truncated []string // the fields whose values do not fit into the columns
+	CodePair  bool     // alpha_3 does not belong to the country of alpha_2
+	FlagFile  bool     // the file url is not in the directories of the flag providers
+}
+
+func (e *ValidationError) Error() string {
+	var problems []string
+	if e.CountryId != nil {
+		problems = append(problems, fmt.Sprintf("country_id %d does not exist", *e.CountryId))
+	}
+	if len(e.Hobbies) != 0 {
+		problems = append(problems, fmt.Sprintf("hobbies %v do not exist", e.Hobbies))
+	}
+	if e.NoHobbies {
+		problems = append(problems, "at least one hobby is required")
+	}
+	if len(e.Truncated) != 0 {
+		problems = append(problems, fmt.Sprintf("values of %v do not fit into the columns", e.Truncated))
+	}
+	if e.CodePair {
+		problems = append(problems, "alpha_3 does not belong to the country of alpha_2")
+	}
+	if e.FlagFile {
+		problems = append(problems, "url is not a file of the flag providers")
+	}
+	return "invalid data: " + strings.Join(problems, "; ")
+}
+
+// Failed reports whether any problem was found.
+func (e *ValidationError) Failed() bool {
+	return e.CountryId != nil || len(e.Hobbies) != 0 || e.NoHobbies || len(e.Truncated) != 0 || e.CodePair || e.FlagFile
+}
+
+// ConflictError reports that the value of the unique field is already used by another object.
+// Field is empty when it is not known which field it is.
+type ConflictError struct {
+	Field string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return "the value is already used"
+	}
+	return fmt.Sprintf("%s is already used", e.Field)
+}
+
+// Reason tells why the caller is not allowed to do the action.
+type Reason string
+
+const (
+	AdminRequired Reason = "admin_required"
+	NotOwner      Reason = "not_owner"
+)
+
+var reasonMessages = map[Reason]string{
+	AdminRequired: "only admins can do this",
+	NotOwner:      "only the owner of the user or admins can do this",
+}
+
+// ForbiddenError is returned when the caller is known but is not allowed to do the action.
+type ForbiddenError struct {
+	Reason Reason
+}
+
+func (e *ForbiddenError) Error() string { return reasonMessages[e.Reason] }
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/joho/godotenv"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 	"tranee_service/handlers"
 	"tranee_service/internal"
+	"tranee_service/internal/blobstore"
 	"tranee_service/internal/databases"
 	"tranee_service/internal/logging"
+	"tranee_service/internal/metrics"
 	"tranee_service/internal/server"
+	"tranee_service/models"
 	"tranee_service/repositories"
 	"tranee_service/services"
 )
@@ -44,14 +53,40 @@ func main() {
 		log.Panicf("Error while initialization database:%s", err)
 	}
 	logger := logging.GetLoggerZap(db)
-	repo := repositories.NewRepository(db, logger)
-	err = repo.SaveInitialCountries(countries)
+	appMetrics := metrics.New(db)
+	repo := repositories.Instrument(repositories.NewRepository(db, logger), appMetrics)
+	config, err := fetchConfig()
 	if err != nil {
-		logger.Fatal(err)
+		logger.Fatalf("Error while configuring loading of images:%s", err)
 	}
-
-	ser := services.NewService(repo, logger)
-	handler := handlers.NewHandler(ser, logger)
+	provider, err := flagProvider()
+	if err != nil {
+		logger.Fatalf("Error while configuring flag providers:%s", err)
+	}
+	storeDir := os.Getenv("FLAG_STORE_DIR")
+	if storeDir == "" {
+		storeDir = "flags"
+	}
+	store, err := blobstore.NewFileStore(storeDir)
+	if err != nil {
+		logger.Fatalf("Error while opening flag store:%s", err)
+	}
+	ser := services.NewService(repo, logger, appMetrics, provider, config, store)
+	authenticator, err := newAuthenticator()
+	if err != nil {
+		logger.Fatalf("Error while configuring authentication:%s", err)
+	}
+	handler := handlers.NewHandler(services.Authorize(ser), logger, authenticator)
+	timeouts := handlers.DefaultTimeouts()
+	if timeouts.Request, err = durationEnv("REQUEST_TIMEOUT", timeouts.Request); err != nil {
+		logger.Fatalf("Error while parsing REQUEST_TIMEOUT:%s", err)
+	}
+	if timeouts.Stream, err = durationEnv("STREAM_TIMEOUT", timeouts.Stream); err != nil {
+		logger.Fatalf("Error while parsing STREAM_TIMEOUT:%s", err)
+	}
+	handler.SetTimeouts(timeouts)
+	router := handler.InitRoutes(appMetrics.Middleware)
+	router.Handle("/metrics", appMetrics.Handler()).Methods(http.MethodGet)
 
 	port, present := os.LookupEnv("API_SERVER_PORT")
 	if !present || port == "" {
@@ -63,30 +98,197 @@ func main() {
 		host = "8090"
 	}
 
-	serv := new(server.Server)
+	shutdownTimeout, err := durationEnv("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		logger.Fatalf("Error while parsing SHUTDOWN_TIMEOUT:%s", err)
+	}
+
+	// The handler answers with a timeout problem when its deadline is over, the server gives it time to send it.
+	writeTimeout := timeouts.Request
+	if timeouts.Stream > writeTimeout {
+		writeTimeout = timeouts.Stream
+	}
+	serv := server.NewServer(host, port, router, writeTimeout+5*time.Second)
+	// The signals are caught from the start, so that a shutdown during the seed drains the server too.
+	quit, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stopSignals()
+
 	logger.Infof("Starting server on %s:%s...", host, port)
-	done := make(chan bool, 1)
 	go func() {
-		if err := serv.Run(host, port, handler.InitRoutes()); err != nil {
+		if err := serv.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Panicf("Error occured while running http server: %s", err.Error())
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
+	// The server is started first, so that the readiness check reports the seed while it is running.
+	err = ser.SeedCountries(quit, countries)
+	if err != nil && quit.Err() == nil {
+		logger.Fatal(err)
+	}
+
 	ticker := time.NewTicker(1 * time.Hour)
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				ser.AppCountries.LoadImages()
-			case <-done:
+				if _, _, err := ser.StartLoadImages(context.Background()); err != nil {
+					logger.Errorf("Error while starting loading of images:%s", err)
+				}
+			case <-quit.Done():
 				return
 			}
 		}
 	}()
-	<-quit
+	<-quit.Done()
 	ticker.Stop()
+	// A second signal stops the process at once.
+	stopSignals()
 
-	done <- true
+	// The server stops accepting connections and waits for the running requests, then the loading of the images
+	// finishes. Whatever is left when the grace period is over is cancelled.
+	logger.Infof("Shutting down, grace period %s...", shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := serv.Shutdown(ctx); err != nil {
+		logger.Errorf("Error while shutting down http server:%s", err)
+	}
+	if err := ser.Shutdown(ctx); err != nil {
+		logger.Errorf("Error while waiting for loading of images:%s", err)
+	}
+	logger.Info("Server stopped")
+	if err := logger.Sync(); err != nil {
+		log.Printf("Error while syncing logger:%s", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("Error while closing database:%s", err)
+	}
+}
+
+// newAuthenticator reads the credentials accepted for the mutating requests. API_KEYS is a comma separated list
+// of "subject:key" or "subject:key:role|role" entries, JWT_KEYS_FILE is a JSON Web Key Set the bearer tokens
+// are checked with.
+func newAuthenticator() (handlers.Authenticator, error) {
+	var authenticators handlers.Authenticators
+	if value := os.Getenv("API_KEYS"); value != "" {
+		keys := handlers.NewAPIKeys()
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("API_KEYS: invalid entry %q", entry)
+			}
+			principal := models.Principal{Subject: parts[0]}
+			if len(parts) == 3 {
+				principal.Roles = strings.Split(parts[2], "|")
+			}
+			keys.Add(parts[1], principal)
+		}
+		authenticators = append(authenticators, keys)
+	}
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		keys, err := handlers.LoadKeySet(path)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, &handlers.JWT{
+			Keys:     keys,
+			Issuer:   os.Getenv("JWT_ISSUER"),
+			Audience: os.Getenv("JWT_AUDIENCE"),
+		})
+	}
+	return authenticators, nil
+}
+
+// durationEnv reads the positive duration from the environment variable, value is returned when it is unset.
+func durationEnv(name string, value time.Duration) (time.Duration, error) {
+	raw, present := os.LookupEnv(name)
+	if !present || raw == "" {
+		return value, nil
+	}
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		return value, err
+	}
+	if duration <= 0 {
+		return value, fmt.Errorf("must be positive, got %s", duration)
+	}
+	return duration, nil
+}
+
+// fetchConfig reads the settings of the loading of the images, the defaults are used for the unset ones.
+// FLAG_RATE of 0 turns the rate limit off.
+func fetchConfig() (services.FetchConfig, error) {
+	config := services.DefaultFetchConfig()
+	var err error
+	if value := os.Getenv("FLAG_WORKERS"); value != "" {
+		if config.Workers, err = strconv.Atoi(value); err != nil {
+			return config, fmt.Errorf("FLAG_WORKERS:%w", err)
+		}
+		if config.Workers < 1 {
+			return config, fmt.Errorf("FLAG_WORKERS: must be positive, got %d", config.Workers)
+		}
+	}
+	if value := os.Getenv("FLAG_RATE"); value != "" {
+		if config.Rate, err = strconv.ParseFloat(value, 64); err != nil {
+			return config, fmt.Errorf("FLAG_RATE:%w", err)
+		}
+		if config.Rate < 0 {
+			return config, fmt.Errorf("FLAG_RATE: must not be negative, got %g", config.Rate)
+		}
+	}
+	if value := os.Getenv("FLAG_BURST"); value != "" {
+		if config.Burst, err = strconv.Atoi(value); err != nil {
+			return config, fmt.Errorf("FLAG_BURST:%w", err)
+		}
+		if config.Burst < 1 {
+			return config, fmt.Errorf("FLAG_BURST: must be positive, got %d", config.Burst)
+		}
+	}
+	if value := os.Getenv("FLAG_TIMEOUT"); value != "" {
+		if config.Timeout, err = time.ParseDuration(value); err != nil {
+			return config, fmt.Errorf("FLAG_TIMEOUT:%w", err)
+		}
+		if config.Timeout <= 0 {
+			return config, fmt.Errorf("FLAG_TIMEOUT: must be positive, got %s", config.Timeout)
+		}
+	}
+	if value := os.Getenv("FLAG_RETRIES"); value != "" {
+		if config.Retries, err = strconv.Atoi(value); err != nil {
+			return config, fmt.Errorf("FLAG_RETRIES:%w", err)
+		}
+		if config.Retries < 0 {
+			return config, fmt.Errorf("FLAG_RETRIES: must not be negative, got %d", config.Retries)
+		}
+	}
+	return config, nil
+}
+
+// flagProvider builds the sources of the flags listed in FLAG_PROVIDERS in the order they are asked: "wikipedia"
+// (WIKIPEDIA_API_URL), "manifest" (FLAG_MANIFEST) and "directory" (FLAG_DIR).
+func flagProvider() (services.FlagProvider, error) {
+	names := os.Getenv("FLAG_PROVIDERS")
+	if names == "" {
+		names = "wikipedia"
+	}
+	var providers services.FallbackProviders
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "wikipedia":
+			baseURL := os.Getenv("WIKIPEDIA_API_URL")
+			if baseURL == "" {
+				baseURL = services.DefaultWikipediaURL
+			}
+			providers = append(providers, services.NewWikipediaProvider(baseURL, http.DefaultClient))
+		case "manifest":
+			provider, err := services.LoadManifestProvider(os.Getenv("FLAG_MANIFEST"))
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		case "directory":
+			providers = append(providers, services.NewDirectoryProvider(os.Getenv("FLAG_DIR")))
+		default:
+			return nil, fmt.Errorf("FLAG_PROVIDERS: unknown provider %q", name)
+		}
+	}
+	return providers, nil
 }
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"tranee_service/MyErrors"
+	"tranee_service/models"
+	"tranee_service/services"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request has no credentials of its kind.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator identifies the caller by the credentials of the request.
+type Authenticator interface {
+	Authenticate(req *http.Request) (*models.Principal, error)
+}
+
+// AuthenticatorFunc is an Authenticator made of a function.
+type AuthenticatorFunc func(req *http.Request) (*models.Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(req *http.Request) (*models.Principal, error) {
+	return f(req)
+}
+
+// Authenticators tries the authenticators in order. The first one that finds its credentials in the request decides.
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(req *http.Request) (*models.Principal, error) {
+	for _, authenticator := range a {
+		principal, err := authenticator.Authenticate(req)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// APIKeys authenticates the static keys sent in the X-API-Key header. Only the hashes of the keys are kept,
+// so that the lookup does not depend on how many characters of a guessed key are right.
+type APIKeys struct {
+	principals map[[sha256.Size]byte]models.Principal
+}
+
+func NewAPIKeys() *APIKeys {
+	return &APIKeys{principals: make(map[[sha256.Size]byte]models.Principal)}
+}
+
+func (a *APIKeys) Add(key string, principal models.Principal) {
+	a.principals[sha256.Sum256([]byte(key))] = principal
+}
+
+func (a *APIKeys) Authenticate(req *http.Request) (*models.Principal, error) {
+	key := req.Header.Get("X-API-Key")
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	principal, ok := a.principals[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, errors.New("unknown api key")
+	}
+	return &principal, nil
+}
+
+// withAuthentication identifies the caller. Reads can stay anonymous, the other methods are rejected with 401
+// without valid credentials. Without an authenticator every mutating request is rejected.
+func (h *Handler) withAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var principal *models.Principal
+		err := ErrNoCredentials
+		if h.authenticator != nil {
+			principal, err = h.authenticator.Authenticate(req)
+		}
+		if err != nil && !errors.Is(err, ErrNoCredentials) {
+			h.log(req).Warnf("Authentication failed:%s", err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			h.writeProblem(w, req, MyErrors.Unauthorized, "invalid credentials")
+			return
+		}
+		if principal == nil {
+			if isRead(req.Method) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.writeProblem(w, req, MyErrors.Unauthorized, "credentials are required")
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(services.WithPrincipal(req.Context(), principal)))
+	})
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
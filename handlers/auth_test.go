@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+	"tranee_service/services"
+)
+
+// testAuthenticator lets every request of the handler tests in.
+var testAuthenticator = AuthenticatorFunc(func(req *http.Request) (*models.Principal, error) {
+	return &models.Principal{Subject: "test"}, nil
+})
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthentication(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("test secret")
+	keys := NewKeySet()
+	keys.AddHMAC("hmac", secret)
+	keys.AddRSA("rsa", &rsaKey.PublicKey)
+	apiKeys := NewAPIKeys()
+	apiKeys.Add("test key", models.Principal{Subject: "ci"})
+	authenticator := Authenticators{apiKeys, &JWT{Keys: keys, Issuer: "test issuer"}}
+
+	validClaims := Claims{
+		Roles:  []string{"admin"},
+		UserId: 7,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "7",
+			Issuer:    "test issuer",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	expiredClaims := validClaims
+	expiredClaims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	noExpirationClaims := validClaims
+	noExpirationClaims.ExpiresAt = nil
+	otherIssuerClaims := validClaims
+	otherIssuerClaims.Issuer = "other issuer"
+
+	testTable := []struct {
+		name               string
+		method             string
+		header             string
+		value              string
+		expectedStatusCode int
+		expectedPrincipal  *models.Principal
+	}{
+		{
+			name:               "Anonymous read",
+			method:             "GET",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Anonymous write",
+			method:             "DELETE",
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "API key",
+			method:             "DELETE",
+			header:             "X-API-Key",
+			value:              "test key",
+			expectedStatusCode: 200,
+			expectedPrincipal:  &models.Principal{Subject: "ci"},
+		},
+		{
+			name:               "Unknown API key",
+			method:             "DELETE",
+			header:             "X-API-Key",
+			value:              "wrong key",
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "HS256 token",
+			method:             "DELETE",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodHS256, "hmac", secret, validClaims),
+			expectedStatusCode: 200,
+			expectedPrincipal:  &models.Principal{Subject: "7", Roles: []string{"admin"}, UserId: 7},
+		},
+		{
+			name:               "RS256 token",
+			method:             "POST",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims),
+			expectedStatusCode: 200,
+			expectedPrincipal:  &models.Principal{Subject: "7", Roles: []string{"admin"}, UserId: 7},
+		},
+		{
+			name:               "RS256 token without key id",
+			method:             "POST",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodRS256, "", rsaKey, validClaims),
+			expectedStatusCode: 200,
+			expectedPrincipal:  &models.Principal{Subject: "7", Roles: []string{"admin"}, UserId: 7},
+		},
+		{
+			name:               "Token signed with other key",
+			method:             "POST",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodRS256, "rsa", otherKey, validClaims),
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Token signed with other algorithm",
+			method:             "POST",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodHS512, "hmac", secret, validClaims),
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Expired token",
+			method:             "PUT",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodHS256, "hmac", secret, expiredClaims),
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Token without expiration time",
+			method:             "PUT",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodHS256, "hmac", secret, noExpirationClaims),
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Token of other issuer",
+			method:             "PUT",
+			header:             "Authorization",
+			value:              "Bearer " + signToken(t, jwt.SigningMethodHS256, "hmac", secret, otherIssuerClaims),
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Invalid token on read",
+			method:             "GET",
+			header:             "Authorization",
+			value:              "Bearer invalid",
+			expectedStatusCode: 401,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(&services.Service{}, logging.GetLoggerLogrus(), authenticator)
+			r := handler.InitRoutes()
+			var principal *models.Principal
+			r.HandleFunc("/test", func(w http.ResponseWriter, req *http.Request) {
+				principal = services.PrincipalFromContext(req.Context())
+			})
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest(testCase.method, "/test", nil)
+			if testCase.header != "" {
+				req.Header.Set(testCase.header, testCase.value)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedPrincipal, principal)
+			if w.Code == 401 {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := fmt.Sprintf(`{"keys":[{"kty":"oct","kid":"hmac","k":%q},{"kty":"RSA","kid":"rsa","n":%q,"e":%q}]}`,
+		encode([]byte("test secret")), encode(rsaKey.N.Bytes()), encode(big.NewInt(int64(rsaKey.E)).Bytes()))
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadKeySet(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test secret"), keys.keys["HS256"]["hmac"])
+	assert.Equal(t, &rsaKey.PublicKey, keys.keys["RS256"]["rsa"])
+}
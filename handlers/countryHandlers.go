@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/asaskevich/govalidator"
 	"net/http"
@@ -19,8 +18,8 @@ func (h *Handler) getAllCountries(w http.ResponseWriter, req *http.Request) {
 	if req.URL.Query().Get("page") != "" {
 		paramPage, err := strconv.Atoi(req.URL.Query().Get("page"))
 		if err != nil || paramPage < 0 {
-			h.logger.Warnf("Invalid url request:%s", err)
-			http.Error(w, "invalid url request", 400)
+			h.log(req).Warnf("Invalid url request:%s", err)
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
 			return
 		}
 		filters.Page = uint64(paramPage)
@@ -28,8 +27,8 @@ func (h *Handler) getAllCountries(w http.ResponseWriter, req *http.Request) {
 	if req.URL.Query().Get("limit") != "" {
 		paramLimit, err := strconv.Atoi(req.URL.Query().Get("limit"))
 		if err != nil || paramLimit < 0 {
-			h.logger.Warnf("Invalid url request:%s", err)
-			http.Error(w, "invalid url request", 400)
+			h.log(req).Warnf("Invalid url request:%s", err)
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
 			return
 		}
 		filters.Limit = uint64(paramLimit)
@@ -37,8 +36,8 @@ func (h *Handler) getAllCountries(w http.ResponseWriter, req *http.Request) {
 	if req.URL.Query().Get("chunk") != "" {
 		paramChunk := req.URL.Query().Get("chunk")
 		if paramChunk != "true" && paramChunk != "false" {
-			h.logger.Warnf("Invalid parameter 'chunk' passed")
-			http.Error(w, fmt.Sprintf("invalid parameter 'chunk' passed"), 400)
+			h.log(req).Warnf("Invalid parameter 'chunk' passed")
+			h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid parameter 'chunk' passed"))
 			return
 		}
 		if paramChunk == "true" {
@@ -47,41 +46,106 @@ func (h *Handler) getAllCountries(w http.ResponseWriter, req *http.Request) {
 			chunk = false
 		}
 	}
+	filters.Name = req.URL.Query().Get("name")
+	filters.EnglishName = req.URL.Query().Get("english_name")
+	filters.Location = req.URL.Query().Get("location")
+	filters.LocationPrecise = req.URL.Query().Get("location_precise")
+	if req.URL.Query().Get("iso_from") != "" {
+		paramIsoFrom, err := strconv.Atoi(req.URL.Query().Get("iso_from"))
+		if err != nil || paramIsoFrom < 0 {
+			h.log(req).Warnf("Invalid url request:%s", err)
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
+			return
+		}
+		filters.IsoFrom = paramIsoFrom
+	}
+	if req.URL.Query().Get("iso_to") != "" {
+		paramIsoTo, err := strconv.Atoi(req.URL.Query().Get("iso_to"))
+		if err != nil || paramIsoTo < 0 {
+			h.log(req).Warnf("Invalid url request:%s", err)
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
+			return
+		}
+		filters.IsoTo = paramIsoTo
+	}
+	if filters.IsoFrom != 0 && filters.IsoTo != 0 && filters.IsoFrom > filters.IsoTo {
+		h.log(req).Warnf("Invalid iso range: %d > %d", filters.IsoFrom, filters.IsoTo)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
+		return
+	}
+	sort, err := parseSort(req.URL.Query().Get("sort"), models.CountrySortColumns)
+	if err != nil {
+		h.log(req).Warnf("Invalid parameter 'sort' passed:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid parameter 'sort' passed: %s", err))
+		return
+	}
+	filters.Sort = sort
+	envelope, err := wantsEnvelope(req)
+	if err != nil {
+		h.log(req).Warnf("%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	if envelope && chunk {
+		// The chunks are the countries one by one, there is nothing to wrap them in.
+		h.log(req).Warnf("Envelope requested with 'chunk'")
+		h.writeProblem(w, req, MyErrors.ValidationFailed, "the envelope can not be used with 'chunk'")
+		return
+	}
+	if req.URL.Query().Has("cursor") {
+		if filters.Page != 0 {
+			h.log(req).Warnf("Parameters 'page' and 'cursor' passed together")
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "parameters 'page' and 'cursor' can not be used together")
+			return
+		}
+		filters.Keyset = true
+		filters.Cursor = req.URL.Query().Get("cursor")
+	}
 
-	countries, pages, err := h.service.GetCountries(&filters)
+	countries, page, err := h.service.GetCountries(req.Context(), &filters)
 	if err != nil {
-		h.logger.Warnf("server error: %s", err)
-		http.Error(w, "server error", 500)
+		h.writeError(w, req, err)
 		return
 	}
+	if page.NextCursor != "" {
+		w.Header().Set("Link", nextLink(req, page.NextCursor))
+	}
 
 	if chunk == false {
-		output, err := json.Marshal(countries)
+		var body interface{} = countries
+		if envelope {
+			body = models.Envelope{Data: countries, Meta: newMeta(filters.Page, filters.Limit, filters.Keyset, page)}
+		}
+		output, err := json.Marshal(body)
 		if err != nil {
-			h.logger.Errorf("getAllCountries: error while marshaling list of countries: %s", err)
-			http.Error(w, fmt.Sprintf("getAllCountries: error while marshaling list of countries: %s", err), 500)
+			h.log(req).Errorf("getAllCountries: error while marshaling list of countries: %s", err)
+			h.writeProblem(w, req, MyErrors.Internal, "")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Pages", strconv.Itoa(pages))
+		if !filters.Keyset {
+			w.Header().Set("Pages", strconv.Itoa(page.Pages))
+		}
 		_, err = w.Write(output)
 		if err != nil {
-			h.logger.Errorf("getAllCountries: error while writing response:%s", err)
-			http.Error(w, fmt.Sprintf("getAllCountries: error while writing response:%s", err), 500)
+			h.log(req).Errorf("getAllCountries: error while writing response:%s", err)
+			h.writeProblem(w, req, MyErrors.Internal, "")
 			return
 		}
 	} else {
 		flusher, ok := w.(http.Flusher)
 		if !ok {
-			h.logger.Errorf("getAllCountries: %s", err)
+			h.log(req).Errorf("getAllCountries: response writer does not support streaming")
+			h.writeProblem(w, req, MyErrors.Internal, "")
+			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Connection", "keep-alive")
 		for _, country := range countries {
 			output, err := json.Marshal(country)
 			if err != nil {
-				h.logger.Errorf("getAllCountries: error while marshaling one country: %s", err)
-				http.Error(w, fmt.Sprintf("getAllCountries: error while marshaling marshaling one country: %s", err), 500)
+				h.log(req).Errorf("getAllCountries: error while marshaling one country: %s", err)
+				h.writeProblem(w, req, MyErrors.Internal, "")
 				return
 			}
 			addBytes := []byte("\n")
@@ -90,8 +154,8 @@ func (h *Handler) getAllCountries(w http.ResponseWriter, req *http.Request) {
 			}
 			_, err = w.Write(output)
 			if err != nil {
-				h.logger.Errorf("getAllCountries: error while writing response:%s", err)
-				http.Error(w, fmt.Sprintf("getAllCountries: error while writing response:%s", err), 500)
+				h.log(req).Errorf("getAllCountries: error while writing response:%s", err)
+				h.writeProblem(w, req, MyErrors.Internal, "")
 				return
 			}
 			flusher.Flush()
@@ -101,34 +165,28 @@ func (h *Handler) getAllCountries(w http.ResponseWriter, req *http.Request) {
 
 func (h *Handler) getOneCountry(w http.ResponseWriter, req *http.Request) {
 	countryId := strings.TrimPrefix(req.URL.Path, "/countries/")
-	if !govalidator.IsAlpha(countryId) {
-		h.logger.Warnf("Invalid url parameter")
-		http.Error(w, "invalid url parameter", 400)
+	if !isCountryCode(countryId) {
+		h.log(req).Warnf("Invalid url parameter")
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url parameter")
 		return
 	}
 	countryId = strings.ToUpper(countryId)
-	country, err := h.service.GetOneCountry(countryId)
+	country, err := h.service.GetOneCountry(req.Context(), countryId)
 	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("getOneCountry: such country does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
-		}
-		h.logger.Warnf("getOneCountry: server error: %s", err)
-		http.Error(w, "server error", 500)
+		h.writeError(w, req, err)
 		return
 	}
 	output, err := json.Marshal(country)
 	if err != nil {
-		h.logger.Errorf("getOneCountry: error while marshaling one country: %s", err)
-		http.Error(w, fmt.Sprintf("getOneCountry: error while marshaling one country: %s", err), 500)
+		h.log(req).Errorf("getOneCountry: error while marshaling one country: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(output)
 	if err != nil {
-		h.logger.Errorf("getOneCountry: error while writing response:%s", err)
-		http.Error(w, fmt.Sprintf("getOneCountry: error while writing response:%s", err), 500)
+		h.log(req).Errorf("getOneCountry: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 }
@@ -137,20 +195,19 @@ func (h *Handler) createCountry(w http.ResponseWriter, req *http.Request) {
 	var input models.ResponseCountry
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
-		h.logger.Errorf("Error while decoding request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	result, err := govalidator.ValidateStruct(input)
 	if !result {
-		h.logger.Errorf("incorrect data came from the request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
-	countryId, err := h.service.CreateCountry(&input)
+	countryId, err := h.service.CreateCountry(req.Context(), &input)
 	if err != nil {
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
 		return
 	}
 	w.Header().Set("id", countryId)
@@ -161,59 +218,77 @@ func (h *Handler) changeCountry(w http.ResponseWriter, req *http.Request) {
 	var input models.ResponseCountry
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
-		h.logger.Errorf("Error while decoding request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	result, err := govalidator.ValidateStruct(input)
 	if !result {
-		h.logger.Errorf("Incorrect data came from the request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	countryId := strings.TrimPrefix(req.URL.Path, "/countries/")
-	if !govalidator.IsAlpha(countryId) {
-		h.logger.Warnf("Invalid url parameter")
-		http.Error(w, "invalid url parameter", 400)
+	if !isCountryCode(countryId) {
+		h.log(req).Warnf("Invalid url parameter")
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url parameter")
 		return
 	}
 	countryId = strings.ToUpper(countryId)
-	err = h.service.ChangeCountry(&input, countryId)
+	newId, err := h.service.ChangeCountry(req.Context(), &input, countryId)
 	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("changeCountry: such country does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
-		}
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
+		return
+	}
+	if newId != "" {
+		w.Header().Set("Location", "/countries/"+newId)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) patchCountry(w http.ResponseWriter, req *http.Request) {
+	var input models.CountryPatch
+	if err := decodeMergePatch(req, &input); err != nil {
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	result, err := govalidator.ValidateStruct(input)
+	if !result {
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
+	countryId := strings.TrimPrefix(req.URL.Path, "/countries/")
+	if !isCountryCode(countryId) {
+		h.log(req).Warnf("Invalid url parameter")
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url parameter")
+		return
+	}
+	countryId = strings.ToUpper(countryId)
+	newId, err := h.service.PatchCountry(req.Context(), &input, countryId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	if newId != "" {
+		w.Header().Set("Location", "/countries/"+newId)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handler) deleteCountry(w http.ResponseWriter, req *http.Request) {
 	reqId := strings.TrimPrefix(req.URL.Path, "/countries/")
-	if !govalidator.IsAlpha(reqId) {
-		h.logger.Warnf("Invalid url parameter")
-		http.Error(w, "invalid url parameter", 400)
+	if !isCountryCode(reqId) {
+		h.log(req).Warnf("Invalid url parameter")
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url parameter")
 		return
 	}
 	reqId = strings.ToUpper(reqId)
-	err := h.service.DeleteCountry(reqId)
+	err := h.service.DeleteCountry(req.Context(), reqId)
 	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("deleteCountry: such country does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
-		}
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
-
-func (h *Handler) loadImages(w http.ResponseWriter, req *http.Request) {
-	go h.service.LoadImages()
-}
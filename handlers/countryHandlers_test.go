@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
+	"tranee_service/repositories"
 	"tranee_service/services"
 	mockservice "tranee_service/services/mocks"
 )
@@ -31,7 +33,7 @@ func TestGetAllCountries(t *testing.T) {
 			pathQuery:   "?page=1&limit=2",
 			inputFilter: &models.Filters{Page: 1, Limit: 2},
 			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
-				s.EXPECT().GetCountries(filter).Return([]models.Country{
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return([]models.Country{
 					{
 						Name:            "test name",
 						FullName:        "test full name",
@@ -54,17 +56,31 @@ func TestGetAllCountries(t *testing.T) {
 						LocationPrecise: "test location precise",
 						Url:             "test url2",
 					},
-				}, 1, nil)
+				}, models.PageInfo{Pages: 1}, nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `[{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise","url":"test url"},{"name":"test name2","full_name":"test full name2","english_name":"test english name2","alpha_2":"tp","alpha_3":"tpt","iso":1001,"location":"test location","location_precise":"test location precise","url":"test url2"}]`,
 		},
+		{
+			name:        "OK chunked",
+			pathQuery:   "?page=1&limit=2&chunk=true",
+			inputFilter: &models.Filters{Page: 1, Limit: 2},
+			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return([]models.Country{
+					{Name: "test name", Alpha2: "tt", Alpha3: "ttt", Iso: 1000},
+					{Name: "test name2", Alpha2: "tp", Alpha3: "tpt", Iso: 1001},
+				}, models.PageInfo{Pages: 1}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"name":"test name","full_name":"","english_name":"","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"","location_precise":"","url":""}` + "\n" +
+				`{"name":"test name2","full_name":"","english_name":"","alpha_2":"tp","alpha_3":"tpt","iso":1001,"location":"","location_precise":"","url":""}` + "\n",
+		},
 		{
 			name:        "OK without pagination",
 			pathQuery:   "",
 			inputFilter: &models.Filters{},
 			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
-				s.EXPECT().GetCountries(filter).Return([]models.Country{
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return([]models.Country{
 					{
 						Name:            "test name",
 						FullName:        "test full name",
@@ -87,18 +103,116 @@ func TestGetAllCountries(t *testing.T) {
 						LocationPrecise: "test location precise",
 						Url:             "test url2",
 					},
-				}, 1, nil)
+				}, models.PageInfo{Pages: 1}, nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `[{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise","url":"test url"},{"name":"test name2","full_name":"test full name2","english_name":"test english name2","alpha_2":"tp","alpha_3":"tpt","iso":1001,"location":"test location","location_precise":"test location precise","url":"test url2"}]`,
 		},
+		{
+			name:        "OK with filters",
+			pathQuery:   "?location=Europe&name=land&english_name=land&iso_from=100&iso_to=300&sort=-iso",
+			inputFilter: &models.Filters{Name: "land", EnglishName: "land", Location: "Europe", IsoFrom: 100, IsoTo: 300, Sort: []models.SortField{{Field: "iso", Desc: true}}},
+			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return([]models.Country{
+					{
+						Name:            "test land",
+						FullName:        "test full name",
+						EnglishName:     "test english land",
+						Alpha2:          "tt",
+						Alpha3:          "ttt",
+						Iso:             200,
+						Location:        "Europe",
+						LocationPrecise: "test location precise",
+						Url:             "test url",
+					},
+				}, models.PageInfo{Pages: 1}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `[{"name":"test land","full_name":"test full name","english_name":"test english land","alpha_2":"tt","alpha_3":"ttt","iso":200,"location":"Europe","location_precise":"test location precise","url":"test url"}]`,
+		},
+		{
+			name:        "OK with envelope",
+			pathQuery:   "?page=2&limit=1&envelope=true",
+			inputFilter: &models.Filters{Page: 2, Limit: 1},
+			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return([]models.Country{
+					{
+						Name:            "test name",
+						FullName:        "test full name",
+						EnglishName:     "test english name",
+						Alpha2:          "tt",
+						Alpha3:          "ttt",
+						Iso:             1000,
+						Location:        "test location",
+						LocationPrecise: "test location precise",
+						Url:             "test url",
+					},
+				}, models.PageInfo{Total: 3, Pages: 3}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise","url":"test url"}],"meta":{"page":2,"limit":1,"total":3,"pages":3}}`,
+		},
+		{
+			name:        "OK with envelope and cursor",
+			pathQuery:   "?limit=1&cursor=&envelope=true",
+			inputFilter: &models.Filters{Limit: 1, Keyset: true},
+			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return([]models.Country{
+					{
+						Name:            "test name",
+						FullName:        "test full name",
+						EnglishName:     "test english name",
+						Alpha2:          "tt",
+						Alpha3:          "ttt",
+						Iso:             1000,
+						Location:        "test location",
+						LocationPrecise: "test location precise",
+						Url:             "test url",
+					},
+				}, models.PageInfo{NextCursor: "next"}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise","url":"test url"}],"meta":{"limit":1,"total":null,"pages":null,"next_cursor":"next"}}`,
+		},
+		{
+			name:                "Envelope with chunk",
+			pathQuery:           "?chunk=true&envelope=true",
+			inputFilter:         &models.Filters{},
+			mockBehavior:        func(s *mockservice.MockAppCountries, filter *models.Filters) {},
+			expectedStatusCode:  422,
+			expectedRequestBody: `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"the envelope can not be used with 'chunk'","code":"validation_failed"}`,
+		},
+		{
+			name:                "Invalid envelope",
+			pathQuery:           "?envelope=yes",
+			inputFilter:         &models.Filters{},
+			mockBehavior:        func(s *mockservice.MockAppCountries, filter *models.Filters) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid parameter 'envelope' passed","code":"invalid_request"}`,
+		},
+		{
+			name:                "Invalid sort",
+			pathQuery:           "?sort=iso,-url",
+			inputFilter:         &models.Filters{},
+			mockBehavior:        func(s *mockservice.MockAppCountries, filter *models.Filters) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid parameter 'sort' passed: field \"url\" can not be used for sorting","code":"invalid_request"}`,
+		},
+		{
+			name:                "Invalid iso range",
+			pathQuery:           "?iso_from=300&iso_to=100",
+			inputFilter:         &models.Filters{},
+			mockBehavior:        func(s *mockservice.MockAppCountries, filter *models.Filters) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
+		},
 		{
 			name:                "Invalid query",
 			pathQuery:           "?page=-1&limit=2",
 			inputFilter:         &models.Filters{},
 			mockBehavior:        func(s *mockservice.MockAppCountries, filter *models.Filters) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:                "Invalid query2",
@@ -106,17 +220,17 @@ func TestGetAllCountries(t *testing.T) {
 			inputFilter:         &models.Filters{},
 			mockBehavior:        func(s *mockservice.MockAppCountries, filter *models.Filters) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:        "Server error",
 			pathQuery:   "?page=1&limit=2",
 			inputFilter: &models.Filters{Page: 1, Limit: 2},
 			mockBehavior: func(s *mockservice.MockAppCountries, filter *models.Filters) {
-				s.EXPECT().GetCountries(filter).Return(nil, 0, errors.New("server error"))
+				s.EXPECT().GetCountries(gomock.Any(), filter).Return(nil, models.PageInfo{}, errors.New("server error"))
 			},
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
 		},
 	}
 
@@ -128,7 +242,7 @@ func TestGetAllCountries(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputFilter)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppCountries: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -160,7 +274,7 @@ func TestGetOneCountry(t *testing.T) {
 			pathId:  "tt",
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, inputId string) {
-				s.EXPECT().GetOneCountry(inputId).Return(&models.Country{
+				s.EXPECT().GetOneCountry(gomock.Any(), inputId).Return(&models.Country{
 					Name:            "test name",
 					FullName:        "test full name",
 					EnglishName:     "test english name",
@@ -181,27 +295,35 @@ func TestGetOneCountry(t *testing.T) {
 			inputId:             "",
 			mockBehavior:        func(s *mockservice.MockAppCountries, inputId string) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url parameter\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url parameter","code":"invalid_request"}`,
+		},
+		{
+			name:                "Id is neither alpha_2 nor alpha_3",
+			pathId:              "test",
+			inputId:             "",
+			mockBehavior:        func(s *mockservice.MockAppCountries, inputId string) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url parameter","code":"invalid_request"}`,
 		},
 		{
 			name:    "Such a news does not exist",
 			pathId:  "tt",
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, inputId string) {
-				s.EXPECT().GetOneCountry(inputId).Return(nil, MyErrors.DoesNotExist)
+				s.EXPECT().GetOneCountry(gomock.Any(), inputId).Return(nil, MyErrors.DoesNotExist)
 			},
 			expectedStatusCode:  404,
-			expectedRequestBody: "object with this id does not exist\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
 		},
 		{
 			name:    "Server error",
 			pathId:  "tt",
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, inputId string) {
-				s.EXPECT().GetOneCountry(inputId).Return(nil, errors.New("server error"))
+				s.EXPECT().GetOneCountry(gomock.Any(), inputId).Return(nil, errors.New("server error"))
 			},
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
 		},
 	}
 
@@ -213,7 +335,7 @@ func TestGetOneCountry(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppCountries: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -229,6 +351,54 @@ func TestGetOneCountry(t *testing.T) {
 	}
 }
 
+// TestUnknownCountry checks the answer for the unknown country with the real services and repositories,
+// the way the id is checked before the country is read.
+func TestUnknownCountry(t *testing.T) {
+	testTable := []struct {
+		name string
+		path string
+	}{
+		{
+			name: "Country",
+			path: "/countries/zz",
+		},
+		{
+			name: "Country by alpha_3",
+			path: "/countries/zzz",
+		},
+		{
+			name: "Flag",
+			path: "/countries/zz/flag",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			mock.ExpectQuery("SELECT EXISTS ").WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(false))
+			logger := logging.GetLoggerLogrus()
+			serv := services.NewService(repositories.NewRepository(db, logger), logger, nil, nil, services.FetchConfig{}, nil)
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("GET", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, 404, w.Code)
+			assert.Equal(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestCreateCountry(t *testing.T) {
 	type mockBehavior func(s *mockservice.MockAppCountries, country *models.ResponseCountry)
 
@@ -241,19 +411,19 @@ func TestCreateCountry(t *testing.T) {
 	}{
 		{
 			name:      "OK",
-			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise"}`,
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
 			inputCountry: &models.ResponseCountry{
 				Name:            "test name",
 				FullName:        "test full name",
 				EnglishName:     "test english name",
-				Alpha2:          "tt",
-				Alpha3:          "ttt",
-				Iso:             1000,
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
 				Location:        "test location",
 				LocationPrecise: "test location precise",
 			},
 			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {
-				s.EXPECT().CreateCountry(country).Return("tt", nil)
+				s.EXPECT().CreateCountry(gomock.Any(), country).Return("XT", nil)
 			},
 			expectedStatusCode: 201,
 		},
@@ -264,21 +434,49 @@ func TestCreateCountry(t *testing.T) {
 			mockBehavior:       func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {},
 			expectedStatusCode: 400,
 		},
+		{
+			name:               "Lowercase alpha_2",
+			inputBody:          `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"xt","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
+			inputCountry:       &models.ResponseCountry{},
+			mockBehavior:       func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "ISO is not a 3-digit number",
+			inputBody:          `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":1000,"location":"test location","location_precise":"test location precise"}`,
+			inputCountry:       &models.ResponseCountry{},
+			mockBehavior:       func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Alpha_3 of another country",
+			inputBody:          `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"RS","alpha_3":"RSB","iso":688,"location":"test location","location_precise":"test location precise"}`,
+			inputCountry:       &models.ResponseCountry{},
+			mockBehavior:       func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Url is neither http nor file",
+			inputBody:          `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise","url":"ftp://example.com/flag.svg"}`,
+			inputCountry:       &models.ResponseCountry{},
+			mockBehavior:       func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {},
+			expectedStatusCode: 400,
+		},
 		{
 			name:      "Server error",
-			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise"}`,
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
 			inputCountry: &models.ResponseCountry{
 				Name:            "test name",
 				FullName:        "test full name",
 				EnglishName:     "test english name",
-				Alpha2:          "tt",
-				Alpha3:          "ttt",
-				Iso:             1000,
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
 				Location:        "test location",
 				LocationPrecise: "test location precise",
 			},
 			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry) {
-				s.EXPECT().CreateCountry(country).Return("", errors.New("server error"))
+				s.EXPECT().CreateCountry(gomock.Any(), country).Return("", errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
@@ -292,7 +490,7 @@ func TestCreateCountry(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputCountry)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppCountries: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -318,27 +516,89 @@ func TestChangeCountry(t *testing.T) {
 		inputId            string
 		mockBehavior       mockBehavior
 		expectedStatusCode int
+		expectedLocation   string
 	}{
 		{
 			name:      "OK",
 			pathId:    "tt",
-			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise"}`,
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
 			inputCountry: &models.ResponseCountry{
 				Name:            "test name",
 				FullName:        "test full name",
 				EnglishName:     "test english name",
-				Alpha2:          "tt",
-				Alpha3:          "ttt",
-				Iso:             1000,
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
 				Location:        "test location",
 				LocationPrecise: "test location precise",
 			},
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry, countryId string) {
-				s.EXPECT().ChangeCountry(country, countryId).Return(nil)
+				s.EXPECT().ChangeCountry(gomock.Any(), country, countryId).Return("", nil)
 			},
 			expectedStatusCode: 204,
 		},
+		{
+			name:      "OK alpha_2 changed",
+			pathId:    "oo",
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
+			inputCountry: &models.ResponseCountry{
+				Name:            "test name",
+				FullName:        "test full name",
+				EnglishName:     "test english name",
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
+				Location:        "test location",
+				LocationPrecise: "test location precise",
+			},
+			inputId: "OO",
+			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry, countryId string) {
+				s.EXPECT().ChangeCountry(gomock.Any(), country, countryId).Return("XT", nil)
+			},
+			expectedStatusCode: 204,
+			expectedLocation:   "/countries/XT",
+		},
+		{
+			name:      "Country with such id does not exist",
+			pathId:    "tt",
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
+			inputCountry: &models.ResponseCountry{
+				Name:            "test name",
+				FullName:        "test full name",
+				EnglishName:     "test english name",
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
+				Location:        "test location",
+				LocationPrecise: "test location precise",
+			},
+			inputId: "TT",
+			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry, countryId string) {
+				s.EXPECT().ChangeCountry(gomock.Any(), country, countryId).Return("", MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:      "Another country has such alpha_3",
+			pathId:    "tt",
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
+			inputCountry: &models.ResponseCountry{
+				Name:            "test name",
+				FullName:        "test full name",
+				EnglishName:     "test english name",
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
+				Location:        "test location",
+				LocationPrecise: "test location precise",
+			},
+			inputId: "TT",
+			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry, countryId string) {
+				s.EXPECT().ChangeCountry(gomock.Any(), country, countryId).Return("", &MyErrors.ConflictError{Field: "alpha_3"})
+			},
+			expectedStatusCode: 409,
+		},
 		{
 			name:               "Incorrect data came from the request",
 			pathId:             "tt",
@@ -361,19 +621,19 @@ func TestChangeCountry(t *testing.T) {
 			name:      "Server error",
 			pathId:    "tt",
 			inputId:   "TT",
-			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"tt","alpha_3":"ttt","iso":1000,"location":"test location","location_precise":"test location precise"}`,
+			inputBody: `{"name":"test name","full_name":"test full name","english_name":"test english name","alpha_2":"XT","alpha_3":"XTT","iso":999,"location":"test location","location_precise":"test location precise"}`,
 			inputCountry: &models.ResponseCountry{
 				Name:            "test name",
 				FullName:        "test full name",
 				EnglishName:     "test english name",
-				Alpha2:          "tt",
-				Alpha3:          "ttt",
-				Iso:             1000,
+				Alpha2:          "XT",
+				Alpha3:          "XTT",
+				Iso:             999,
 				Location:        "test location",
 				LocationPrecise: "test location precise",
 			},
 			mockBehavior: func(s *mockservice.MockAppCountries, country *models.ResponseCountry, countryId string) {
-				s.EXPECT().ChangeCountry(country, countryId).Return(errors.New("server error"))
+				s.EXPECT().ChangeCountry(gomock.Any(), country, countryId).Return("", errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
@@ -387,7 +647,7 @@ func TestChangeCountry(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputCountry, testCase.inputId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppCountries: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -398,6 +658,104 @@ func TestChangeCountry(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestPatchCountry(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string)
+	fullName := "test full name"
+	alpha2 := "QQ"
+
+	testTable := []struct {
+		name               string
+		pathId             string
+		inputId            string
+		inputBody          string
+		inputPatch         *models.CountryPatch
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedLocation   string
+	}{
+		{
+			name:       "OK",
+			pathId:     "tt",
+			inputId:    "TT",
+			inputBody:  `{"full_name":"test full name"}`,
+			inputPatch: &models.CountryPatch{FullName: &fullName},
+			mockBehavior: func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string) {
+				s.EXPECT().PatchCountry(gomock.Any(), patch, countryId).Return("", nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:       "OK with new alpha_2",
+			pathId:     "tt",
+			inputId:    "TT",
+			inputBody:  `{"alpha_2":"QQ"}`,
+			inputPatch: &models.CountryPatch{Alpha2: &alpha2},
+			mockBehavior: func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string) {
+				s.EXPECT().PatchCountry(gomock.Any(), patch, countryId).Return("QQ", nil)
+			},
+			expectedStatusCode: 204,
+			expectedLocation:   "/countries/QQ",
+		},
+		{
+			name:               "Field removal",
+			pathId:             "tt",
+			inputBody:          `{"full_name":null}`,
+			mockBehavior:       func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Unknown field",
+			pathId:             "tt",
+			inputBody:          `{"capital":"test"}`,
+			mockBehavior:       func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Incorrect data",
+			pathId:             "tt",
+			inputBody:          `{"alpha_2":"ttqq"}`,
+			mockBehavior:       func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:       "Country with such id does not exist",
+			pathId:     "tt",
+			inputId:    "TT",
+			inputBody:  `{"full_name":"test full name"}`,
+			inputPatch: &models.CountryPatch{FullName: &fullName},
+			mockBehavior: func(s *mockservice.MockAppCountries, patch *models.CountryPatch, countryId string) {
+				s.EXPECT().PatchCountry(gomock.Any(), patch, countryId).Return("", MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppCountries(c)
+			testCase.mockBehavior(appService, testCase.inputPatch, testCase.inputId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppCountries: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("PATCH", fmt.Sprintf("/countries/%s", testCase.pathId), bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedLocation, w.Header().Get("Location"))
 		})
 	}
 }
@@ -417,7 +775,7 @@ func TestDeleteCountry(t *testing.T) {
 			pathId:  "tt",
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, inputId string) {
-				s.EXPECT().DeleteCountry(inputId).Return(nil)
+				s.EXPECT().DeleteCountry(gomock.Any(), inputId).Return(nil)
 			},
 			expectedStatusCode: 204,
 		},
@@ -433,7 +791,7 @@ func TestDeleteCountry(t *testing.T) {
 			pathId:  "tt",
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, inputId string) {
-				s.EXPECT().DeleteCountry(inputId).Return(MyErrors.DoesNotExist)
+				s.EXPECT().DeleteCountry(gomock.Any(), inputId).Return(MyErrors.DoesNotExist)
 			},
 			expectedStatusCode: 404,
 		},
@@ -442,7 +800,7 @@ func TestDeleteCountry(t *testing.T) {
 			pathId:  "tt",
 			inputId: "TT",
 			mockBehavior: func(s *mockservice.MockAppCountries, inputId string) {
-				s.EXPECT().DeleteCountry(inputId).Return(errors.New("server error"))
+				s.EXPECT().DeleteCountry(gomock.Any(), inputId).Return(errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
@@ -456,7 +814,7 @@ func TestDeleteCountry(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppCountries: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"tranee_service/MyErrors"
+	"tranee_service/models"
+)
+
+var statusCodes = map[MyErrors.Code]int{
+	MyErrors.InvalidRequest:   http.StatusBadRequest,
+	MyErrors.Unauthorized:     http.StatusUnauthorized,
+	MyErrors.Forbidden:        http.StatusForbidden,
+	MyErrors.NotFound:         http.StatusNotFound,
+	MyErrors.ValidationFailed: http.StatusUnprocessableEntity,
+	MyErrors.Conflict:         http.StatusConflict,
+	MyErrors.Internal:         http.StatusInternalServerError,
+	MyErrors.Timeout:          http.StatusServiceUnavailable,
+	MyErrors.Unavailable:      http.StatusServiceUnavailable,
+}
+
+// retryAfter is the pause the clients are asked to make when the service is shutting down, the next instance
+// is expected to take the request by then.
+const retryAfter = "5"
+
+// writeError writes the problem that corresponds to the error returned by the service.
+// The text of an unknown error is only logged, since it can contain the messages of the data base driver.
+func (h *Handler) writeError(w http.ResponseWriter, req *http.Request, err error) {
+	var known *MyErrors.Error
+	var validation *MyErrors.ValidationError
+	var conflict *MyErrors.ConflictError
+	var forbidden *MyErrors.ForbiddenError
+	switch {
+	case errors.As(err, &validation):
+		h.log(req).Warnf("%s", err)
+		problem := newProblem(MyErrors.ValidationFailed, validation.Error())
+		problem.InvalidParams = invalidParams(validation)
+		h.writeJSONProblem(w, req, problem)
+	case errors.As(err, &conflict):
+		h.log(req).Warnf("%s", err)
+		problem := newProblem(MyErrors.Conflict, conflict.Error())
+		if conflict.Field != "" {
+			problem.InvalidParams = []models.InvalidParam{{Name: conflict.Field, Reason: "already used"}}
+		}
+		h.writeJSONProblem(w, req, problem)
+	case errors.As(err, &forbidden):
+		h.log(req).Warnf("%s", err)
+		problem := newProblem(MyErrors.Forbidden, forbidden.Error())
+		problem.Reason = forbidden.Reason
+		h.writeJSONProblem(w, req, problem)
+	case errors.Is(err, context.DeadlineExceeded):
+		h.log(req).Warnf("%s", err)
+		h.writeProblem(w, req, MyErrors.Timeout, "the request took too long")
+	case errors.Is(err, context.Canceled):
+		// The client has gone away, nobody reads the response.
+		h.log(req).Warnf("%s", err)
+	case errors.As(err, &known):
+		h.log(req).Warnf("%s", err)
+		if known.Code == MyErrors.Unavailable {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		h.writeProblem(w, req, known.Code, known.Message)
+	default:
+		h.log(req).Errorf("%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+	}
+}
+
+// writeProblem writes the problem with the code and the detail for the client.
+func (h *Handler) writeProblem(w http.ResponseWriter, req *http.Request, code MyErrors.Code, detail string) {
+	h.writeJSONProblem(w, req, newProblem(code, detail))
+}
+
+func (h *Handler) writeJSONProblem(w http.ResponseWriter, req *http.Request, problem models.Problem) {
+	output, err := json.Marshal(problem)
+	if err != nil {
+		h.log(req).Errorf("writeProblem: error while marshaling problem: %s", err)
+		http.Error(w, http.StatusText(problem.Status), problem.Status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	if _, err = w.Write(output); err != nil {
+		h.log(req).Errorf("writeProblem: error while writing response:%s", err)
+	}
+}
+
+func newProblem(code MyErrors.Code, detail string) models.Problem {
+	status, ok := statusCodes[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	return models.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func invalidParams(validation *MyErrors.ValidationError) []models.InvalidParam {
+	var params []models.InvalidParam
+	if validation.CountryId != nil {
+		params = append(params, models.InvalidParam{Name: "country_id", Value: *validation.CountryId, Reason: "does not exist"})
+	}
+	for _, id := range validation.Hobbies {
+		params = append(params, models.InvalidParam{Name: "hobbies", Value: id, Reason: "does not exist"})
+	}
+	if validation.NoHobbies {
+		params = append(params, models.InvalidParam{Name: "hobbies", Reason: "at least one hobby is required"})
+	}
+	for _, field := range validation.Truncated {
+		params = append(params, models.InvalidParam{Name: field, Reason: "does not fit into the column"})
+	}
+	if validation.CodePair {
+		params = append(params, models.InvalidParam{Name: "alpha_3", Reason: "does not belong to the country of alpha_2"})
+	}
+	if validation.FlagFile {
+		params = append(params, models.InvalidParam{Name: "url", Reason: "is not a file of the flag providers"})
+	}
+	return params
+}
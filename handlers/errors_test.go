@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+	"tranee_service/services"
+)
+
+func TestWriteError(t *testing.T) {
+	countryId := 7
+
+	testTable := []struct {
+		name                string
+		inputError          error
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:                "Does not exist",
+			inputError:          fmt.Errorf("getUserById: %w", MyErrors.DoesNotExist),
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
+		},
+		{
+			name:               "Validation failed",
+			inputError:         fmt.Errorf("createUser: %w", &MyErrors.ValidationError{CountryId: &countryId, Hobbies: []int{3}}),
+			expectedStatusCode: 422,
+			expectedRequestBody: `{"type":"about:blank","title":"Unprocessable Entity","status":422,` +
+				`"detail":"invalid data: country_id 7 does not exist; hobbies [3] do not exist","code":"validation_failed",` +
+				`"invalid_params":[{"name":"country_id","value":7,"reason":"does not exist"},{"name":"hobbies","value":3,"reason":"does not exist"}]}`,
+		},
+		{
+			name:               "Codes of different countries",
+			inputError:         fmt.Errorf("patchCountry:%w", &MyErrors.ValidationError{CodePair: true}),
+			expectedStatusCode: 422,
+			expectedRequestBody: `{"type":"about:blank","title":"Unprocessable Entity","status":422,` +
+				`"detail":"invalid data: alpha_3 does not belong to the country of alpha_2","code":"validation_failed",` +
+				`"invalid_params":[{"name":"alpha_3","reason":"does not belong to the country of alpha_2"}]}`,
+		},
+		{
+			name:               "Conflict",
+			inputError:         fmt.Errorf("createUser: %w", &MyErrors.ConflictError{Field: "email"}),
+			expectedStatusCode: 409,
+			expectedRequestBody: `{"type":"about:blank","title":"Conflict","status":409,"detail":"email is already used",` +
+				`"code":"conflict","invalid_params":[{"name":"email","reason":"already used"}]}`,
+		},
+		{
+			name:               "Conflict of unknown field",
+			inputError:         fmt.Errorf("createUser: %w", &MyErrors.ConflictError{}),
+			expectedStatusCode: 409,
+			expectedRequestBody: `{"type":"about:blank","title":"Conflict","status":409,"detail":"the value is already used",` +
+				`"code":"conflict"}`,
+		},
+		{
+			name:               "Forbidden",
+			inputError:         &MyErrors.ForbiddenError{Reason: MyErrors.NotOwner},
+			expectedStatusCode: 403,
+			expectedRequestBody: `{"type":"about:blank","title":"Forbidden","status":403,` +
+				`"detail":"only the owner of the user or admins can do this","code":"forbidden","reason":"not_owner"}`,
+		},
+		{
+			name:                "Query deadline exceeded",
+			inputError:          fmt.Errorf("getUsers: can not executes a query:%w", context.DeadlineExceeded),
+			expectedStatusCode:  503,
+			expectedRequestBody: `{"type":"about:blank","title":"Service Unavailable","status":503,"detail":"the request took too long","code":"timeout"}`,
+		},
+		{
+			name:                "Data base error is not sent to the client",
+			inputError:          errors.New("createCountry: Error 1054: Unknown column 'name' in 'field list'"),
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(&services.Service{}, logging.GetLoggerLogrus(), testAuthenticator)
+			w := httptest.NewRecorder()
+
+			handler.writeError(w, httptest.NewRequest("GET", "/", nil), testCase.inputError)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"tranee_service/MyErrors"
+	"tranee_service/models"
+	"tranee_service/services"
+)
+
+// flagMaxAge is how long the clients keep the image of a flag without asking again. The flags change rarely,
+// and the changed image is found by the ETag when the client asks again.
+const flagMaxAge = 30 * 24 * time.Hour
+
+// getFlag serves the saved image of the flag, or its thumbnail of the width "w". If-None-Match with the ETag
+// of the image gets 304.
+func (h *Handler) getFlag(w http.ResponseWriter, req *http.Request) {
+	countryId := mux.Vars(req)["id"]
+	if !isCountryCode(countryId) {
+		h.log(req).Warnf("Invalid url parameter")
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url parameter")
+		return
+	}
+	var options models.FlagOptions
+	if value := req.URL.Query().Get("w"); value != "" {
+		width, err := strconv.Atoi(value)
+		if err != nil || !isThumbnailWidth(width) {
+			h.log(req).Warnf("Invalid parameter 'w' passed:%s", value)
+			h.writeProblem(w, req, MyErrors.InvalidRequest,
+				fmt.Sprintf("invalid parameter 'w' passed, the widths are %v", services.ThumbnailWidths))
+			return
+		}
+		options.Width = width
+		options.AcceptSVG = acceptsSVG(req)
+		// The same url returns SVG or the thumbnail depending on Accept.
+		w.Header().Set("Vary", "Accept")
+	}
+	flag, err := h.service.GetFlag(req.Context(), strings.ToUpper(countryId), options)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	defer flag.Content.Close()
+	w.Header().Set("Content-Type", flag.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if flag.ContentType == "image/svg+xml" {
+		// SVG can carry scripts, the browser opening the image directly must not run them on this origin.
+		w.Header().Set("Content-Security-Policy", "sandbox")
+	}
+	w.Header().Set("ETag", fmt.Sprintf("%q", flag.Hash))
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(flagMaxAge.Seconds())))
+	http.ServeContent(w, req, "", flag.Updated, flag.Content)
+}
+
+func isThumbnailWidth(width int) bool {
+	for _, allowed := range services.ThumbnailWidths {
+		if width == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsSVG reports whether the Accept header names image/svg+xml with a non-zero quality.
+func acceptsSVG(req *http.Request) bool {
+	for _, header := range req.Header.Values("Accept") {
+		for _, entry := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+			if err != nil || mediaType != "image/svg+xml" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+	"tranee_service/services"
+	mockservice "tranee_service/services/mocks"
+)
+
+// imageContent is the content of the flag returned by the mocked service.
+type imageContent struct {
+	*bytes.Reader
+}
+
+func (imageContent) Close() error { return nil }
+
+func TestGetFlag(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppCountries)
+	flag := func() *models.Flag {
+		return &models.Flag{Content: imageContent{bytes.NewReader([]byte("<svg/>"))}, ContentType: "image/svg+xml",
+			Hash: "1a2b", Updated: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+	}
+
+	testTable := []struct {
+		name                string
+		path                string
+		accept              string
+		ifNoneMatch         string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedContentType string
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			path: "/countries/ru/flag",
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RU", models.FlagOptions{}).Return(flag(), nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "image/svg+xml",
+			expectedRequestBody: "<svg/>",
+		},
+		{
+			name:        "Not modified",
+			path:        "/countries/RUS/flag",
+			ifNoneMatch: `"1a2b"`,
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RUS", models.FlagOptions{}).Return(flag(), nil)
+			},
+			expectedStatusCode: 304,
+		},
+		{
+			name:        "Changed",
+			path:        "/countries/RU/flag",
+			ifNoneMatch: `"3c4d"`,
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RU", models.FlagOptions{}).Return(flag(), nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "image/svg+xml",
+			expectedRequestBody: "<svg/>",
+		},
+		{
+			name: "Thumbnail",
+			path: "/countries/RU/flag?w=64",
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RU", models.FlagOptions{Width: 64}).Return(&models.Flag{
+					Content: imageContent{bytes.NewReader([]byte("png"))}, ContentType: "image/png", Hash: "1a2b"}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "image/png",
+			expectedRequestBody: "png",
+		},
+		{
+			name:   "Thumbnail for client accepting SVG",
+			path:   "/countries/RU/flag?w=64",
+			accept: "image/webp, image/svg+xml;q=0.8, */*;q=0.5",
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RU", models.FlagOptions{Width: 64, AcceptSVG: true}).Return(flag(), nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "image/svg+xml",
+			expectedRequestBody: "<svg/>",
+		},
+		{
+			name:   "Thumbnail for client refusing SVG",
+			path:   "/countries/RU/flag?w=32",
+			accept: "image/svg+xml;q=0, image/png",
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RU", models.FlagOptions{Width: 32}).Return(&models.Flag{
+					Content: imageContent{bytes.NewReader([]byte("png"))}, ContentType: "image/png", Hash: "1a2b"}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "image/png",
+			expectedRequestBody: "png",
+		},
+		{
+			name:                "Invalid width",
+			path:                "/countries/RU/flag?w=65",
+			mockBehavior:        func(s *mockservice.MockAppCountries) {},
+			expectedStatusCode:  400,
+			expectedContentType: "application/problem+json",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,` +
+				`"detail":"invalid parameter 'w' passed, the widths are [16 32 64 128 256 512]","code":"invalid_request"}`,
+		},
+		{
+			name: "Not loaded",
+			path: "/countries/RU/flag",
+			mockBehavior: func(s *mockservice.MockAppCountries) {
+				s.EXPECT().GetFlag(gomock.Any(), "RU", models.FlagOptions{}).Return(nil, MyErrors.FlagNotLoaded)
+			},
+			expectedStatusCode:  404,
+			expectedContentType: "application/problem+json",
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"the flag of the country is not loaded yet","code":"not_found"}`,
+		},
+		{
+			name:                "Invalid id",
+			path:                "/countries/R1/flag",
+			mockBehavior:        func(s *mockservice.MockAppCountries) {},
+			expectedStatusCode:  400,
+			expectedContentType: "application/problem+json",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url parameter","code":"invalid_request"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppCountries(c)
+			testCase.mockBehavior(appService)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppCountries: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("GET", testCase.path, nil)
+			if testCase.accept != "" {
+				req.Header.Set("Accept", testCase.accept)
+			}
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", testCase.ifNoneMatch)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+			if w.Code == 200 || w.Code == 304 {
+				assert.Equal(t, `"1a2b"`, w.Header().Get("ETag"))
+				assert.Equal(t, "public, max-age=2592000", w.Header().Get("Cache-Control"))
+				assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			}
+			if w.Code == 200 && testCase.expectedContentType == "image/svg+xml" {
+				assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+			} else if w.Code == 200 {
+				assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+			}
+		})
+	}
+}
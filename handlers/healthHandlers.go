@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"tranee_service/MyErrors"
+	"tranee_service/models"
+)
+
+func (h *Handler) healthz(w http.ResponseWriter, req *http.Request) {
+	h.writeHealth(w, req, h.service.Live(req.Context()))
+}
+
+func (h *Handler) readyz(w http.ResponseWriter, req *http.Request) {
+	h.writeHealth(w, req, h.service.Ready(req.Context()))
+}
+
+// writeHealth writes the health of the service, 503 tells the orchestrator not to send requests to this instance.
+func (h *Handler) writeHealth(w http.ResponseWriter, req *http.Request, health models.Health) {
+	status := http.StatusOK
+	if health.Status == models.StatusFail {
+		h.log(req).Warnf("Service is not ready: %v", health.Checks)
+		status = http.StatusServiceUnavailable
+	}
+	output, err := json.Marshal(health)
+	if err != nil {
+		h.log(req).Errorf("writeHealth: error while marshaling health:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if _, err = w.Write(output); err != nil {
+		h.log(req).Errorf("writeHealth: error while writing response:%s", err)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+	"tranee_service/services"
+	mockservice "tranee_service/services/mocks"
+)
+
+func TestHealth(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppHealth)
+	version := uint(3)
+
+	testTable := []struct {
+		name                string
+		path                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "Live",
+			path: "/healthz",
+			mockBehavior: func(s *mockservice.MockAppHealth) {
+				s.EXPECT().Live(gomock.Any()).Return(models.Health{Status: models.StatusPass})
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"pass"}`,
+		},
+		{
+			name: "Ready",
+			path: "/readyz",
+			mockBehavior: func(s *mockservice.MockAppHealth) {
+				s.EXPECT().Ready(gomock.Any()).Return(models.Health{
+					Status: models.StatusPass,
+					Checks: map[string]models.Check{
+						"database":    {Status: models.StatusPass},
+						"migrations":  {Status: models.StatusPass, Version: &version},
+						"flag_loader": {Status: models.StatusWarn, LastRun: &models.LoadRun{Checked: 2, Failed: 2, Error: "2 requests to wikipedia failed"}},
+					},
+				})
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"status":"pass","checks":{"database":{"status":"pass"},` +
+				`"flag_loader":{"status":"warn","last_run":{"started":"0001-01-01T00:00:00Z","total":0,"checked":2,"found":0,"failed":2,"skipped":0,"downloaded":0,"error":"2 requests to wikipedia failed"}},` +
+				`"migrations":{"status":"pass","version":3}}}`,
+		},
+		{
+			name: "Not ready",
+			path: "/readyz",
+			mockBehavior: func(s *mockservice.MockAppHealth) {
+				s.EXPECT().Ready(gomock.Any()).Return(models.Health{
+					Status: models.StatusFail,
+					Checks: map[string]models.Check{
+						"database": {Status: models.StatusFail, Detail: "data base is not reachable"},
+					},
+				})
+			},
+			expectedStatusCode:  503,
+			expectedRequestBody: `{"status":"fail","checks":{"database":{"status":"fail","detail":"data base is not reachable"}}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppHealth(c)
+			testCase.mockBehavior(appService)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppHealth: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("GET", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/asaskevich/govalidator"
 	"net/http"
 	"strconv"
+	"strings"
+	"tranee_service/MyErrors"
 	"tranee_service/models"
 )
 
@@ -13,20 +15,19 @@ func (h *Handler) createHobby(w http.ResponseWriter, req *http.Request) {
 	var input models.Hobby
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
-		h.logger.Errorf("Error while decoding request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	result, err := govalidator.ValidateStruct(input)
 	if !result {
-		h.logger.Errorf("Incorrect data came from the request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
-	hobbyId, err := h.service.AppHobbies.CreateHobby(&input)
+	hobbyId, err := h.service.AppHobbies.CreateHobby(req.Context(), &input)
 	if err != nil {
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
 		return
 	}
 	w.Header().Set("id", strconv.Itoa(hobbyId))
@@ -34,23 +35,134 @@ func (h *Handler) createHobby(w http.ResponseWriter, req *http.Request) {
 }
 
 func (h *Handler) getHobbies(w http.ResponseWriter, req *http.Request) {
-	hobbies, err := h.service.AppHobbies.GetHobbies()
+	envelope, err := wantsEnvelope(req)
 	if err != nil {
-		h.logger.Warnf("server error: %s", err)
-		http.Error(w, "server error", 500)
+		h.log(req).Warnf("%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
-	output, err := json.Marshal(hobbies)
+	hobbies, err := h.service.AppHobbies.GetHobbies(req.Context())
 	if err != nil {
-		h.logger.Errorf("getHobbies: error while marshaling list of hobbies: %s", err)
-		http.Error(w, fmt.Sprintf("getHobbies: error while marshaling list of hobbies: %s", err), 500)
+		h.writeError(w, req, err)
+		return
+	}
+	var body interface{} = hobbies
+	if envelope {
+		body = models.Envelope{Data: hobbies, Meta: newMeta(0, 0, false, models.PageInfo{Total: len(hobbies), Pages: 1})}
+	}
+	output, err := json.Marshal(body)
+	if err != nil {
+		h.log(req).Errorf("getHobbies: error while marshaling list of hobbies: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.log(req).Errorf("getHobbies: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+		return
+	}
+}
+
+func (h *Handler) getHobbyById(w http.ResponseWriter, req *http.Request) {
+	paramId := strings.TrimPrefix(req.URL.Path, "/hobbies/")
+	hobbyId, err := strconv.Atoi(paramId)
+	if err != nil || hobbyId <= 0 {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
+		return
+	}
+	hobby, err := h.service.AppHobbies.GetHobbyById(req.Context(), hobbyId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	output, err := json.Marshal(hobby)
+	if err != nil {
+		h.log(req).Errorf("getHobbyById: error while marshaling one hobby: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.log(req).Errorf("getHobbyById: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+		return
+	}
+}
+
+func (h *Handler) changeHobby(w http.ResponseWriter, req *http.Request) {
+	var input models.Hobby
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&input); err != nil {
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	result, err := govalidator.ValidateStruct(input)
+	if !result {
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	paramId := strings.TrimPrefix(req.URL.Path, "/hobbies/")
+	hobbyId, err := strconv.Atoi(paramId)
+	if err != nil || hobbyId <= 0 {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
+		return
+	}
+	err = h.service.AppHobbies.ChangeHobby(req.Context(), &input, hobbyId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) deleteHobby(w http.ResponseWriter, req *http.Request) {
+	paramId := strings.TrimPrefix(req.URL.Path, "/hobbies/")
+	hobbyId, err := strconv.Atoi(paramId)
+	if err != nil || hobbyId <= 0 {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
+		return
+	}
+	err = h.service.AppHobbies.DeleteHobby(req.Context(), hobbyId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getUsersByHobbyId(w http.ResponseWriter, req *http.Request) {
+	paramId := strings.TrimPrefix(req.URL.Path, "/hobbies/")
+	paramId = strings.TrimSuffix(paramId, "/users")
+	hobbyId, err := strconv.Atoi(paramId)
+	if err != nil || hobbyId <= 0 {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
+		return
+	}
+	users, err := h.service.AppHobbies.GetUsersByHobbyId(req.Context(), hobbyId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	output, err := json.Marshal(users)
+	if err != nil {
+		h.log(req).Errorf("getUsersByHobbyId: error while marshaling list of users: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(output)
 	if err != nil {
-		h.logger.Errorf("getHobbies: error while writing response:%s", err)
-		http.Error(w, fmt.Sprintf("getHobbies: error while writing response:%s", err), 500)
+		h.log(req).Errorf("getUsersByHobbyId: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 }
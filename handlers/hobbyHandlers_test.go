@@ -3,10 +3,12 @@ package handlers
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"net/http/httptest"
 	"testing"
+	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 	"tranee_service/services"
@@ -18,6 +20,7 @@ func TestGetHobbies(t *testing.T) {
 
 	testTable := []struct {
 		name                string
+		accept              string
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
@@ -25,7 +28,7 @@ func TestGetHobbies(t *testing.T) {
 		{
 			name: "OK",
 			mockBehavior: func(s *mockservice.MockAppHobbies) {
-				s.EXPECT().GetHobbies().Return([]models.ResponseHobby{
+				s.EXPECT().GetHobbies(gomock.Any()).Return([]models.ResponseHobby{
 					{
 						Id:   1,
 						Name: "test name",
@@ -39,13 +42,27 @@ func TestGetHobbies(t *testing.T) {
 			expectedStatusCode:  200,
 			expectedRequestBody: `[{"id":1,"name":"test name"},{"id":2,"name":"test name2"}]`,
 		},
+		{
+			name:   "OK with envelope",
+			accept: `application/json; profile="envelope"`,
+			mockBehavior: func(s *mockservice.MockAppHobbies) {
+				s.EXPECT().GetHobbies(gomock.Any()).Return([]models.ResponseHobby{
+					{
+						Id:   1,
+						Name: "test name",
+					},
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":1,"name":"test name"}],"meta":{"total":1,"pages":1}}`,
+		},
 		{
 			name: "Server error",
 			mockBehavior: func(s *mockservice.MockAppHobbies) {
-				s.EXPECT().GetHobbies().Return(nil, errors.New("server error"))
+				s.EXPECT().GetHobbies(gomock.Any()).Return(nil, errors.New("server error"))
 			},
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
 		},
 	}
 
@@ -57,13 +74,14 @@ func TestGetHobbies(t *testing.T) {
 			testCase.mockBehavior(appService)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppHobbies: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
 			w := httptest.NewRecorder()
 
 			req := httptest.NewRequest("GET", "/hobbies", nil)
+			req.Header.Set("Accept", testCase.accept)
 
 			r.ServeHTTP(w, req)
 
@@ -88,7 +106,7 @@ func TestCreateHobby(t *testing.T) {
 			inputBody:  `{"name":"testName"}`,
 			inputHobby: &models.Hobby{Name: "testName"},
 			mockBehavior: func(s *mockservice.MockAppHobbies, hobby *models.Hobby) {
-				s.EXPECT().CreateHobby(hobby).Return(1, nil)
+				s.EXPECT().CreateHobby(gomock.Any(), hobby).Return(1, nil)
 			},
 			expectedStatusCode: 201,
 		},
@@ -104,10 +122,19 @@ func TestCreateHobby(t *testing.T) {
 			inputBody:  `{"name":"testName"}`,
 			inputHobby: &models.Hobby{Name: "testName"},
 			mockBehavior: func(s *mockservice.MockAppHobbies, hobby *models.Hobby) {
-				s.EXPECT().CreateHobby(hobby).Return(0, errors.New("server error"))
+				s.EXPECT().CreateHobby(gomock.Any(), hobby).Return(0, errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
+		{
+			name:       "Hobby with such name already exists",
+			inputBody:  `{"name":"testName"}`,
+			inputHobby: &models.Hobby{Name: "testName"},
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobby *models.Hobby) {
+				s.EXPECT().CreateHobby(gomock.Any(), hobby).Return(0, &MyErrors.ConflictError{Field: "name"})
+			},
+			expectedStatusCode: 409,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -118,7 +145,7 @@ func TestCreateHobby(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputHobby)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppHobbies: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -132,3 +159,261 @@ func TestCreateHobby(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHobbyById(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppHobbies, hobbyId int)
+
+	testTable := []struct {
+		name                string
+		pathId              string
+		inputId             int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			pathId:  "1",
+			inputId: 1,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().GetHobbyById(gomock.Any(), hobbyId).Return(&models.ResponseHobby{Id: 1, Name: "test name"}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"name":"test name"}`,
+		},
+		{
+			name:                "Invalid hobby id",
+			pathId:              "a",
+			mockBehavior:        func(s *mockservice.MockAppHobbies, hobbyId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
+		},
+		{
+			name:    "Hobby with such id does not exist",
+			pathId:  "1",
+			inputId: 1,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().GetHobbyById(gomock.Any(), hobbyId).Return(nil, MyErrors.DoesNotExist)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppHobbies(c)
+			testCase.mockBehavior(appService, testCase.inputId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppHobbies: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("GET", fmt.Sprintf("/hobbies/%s", testCase.pathId), nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestChangeHobby(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppHobbies, hobby *models.Hobby, hobbyId int)
+
+	testTable := []struct {
+		name               string
+		pathId             string
+		inputId            int
+		inputBody          string
+		inputHobby         *models.Hobby
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:       "OK",
+			pathId:     "1",
+			inputId:    1,
+			inputBody:  `{"name":"testName"}`,
+			inputHobby: &models.Hobby{Name: "testName"},
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobby *models.Hobby, hobbyId int) {
+				s.EXPECT().ChangeHobby(gomock.Any(), hobby, hobbyId).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Invalid hobby`s name",
+			pathId:             "1",
+			inputBody:          `{"name":"1"}`,
+			mockBehavior:       func(s *mockservice.MockAppHobbies, hobby *models.Hobby, hobbyId int) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:       "Hobby with such id does not exist",
+			pathId:     "1",
+			inputId:    1,
+			inputBody:  `{"name":"testName"}`,
+			inputHobby: &models.Hobby{Name: "testName"},
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobby *models.Hobby, hobbyId int) {
+				s.EXPECT().ChangeHobby(gomock.Any(), hobby, hobbyId).Return(MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppHobbies(c)
+			testCase.mockBehavior(appService, testCase.inputHobby, testCase.inputId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppHobbies: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("PUT", fmt.Sprintf("/hobbies/%s", testCase.pathId), bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestDeleteHobby(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppHobbies, hobbyId int)
+
+	testTable := []struct {
+		name               string
+		pathId             string
+		inputId            int
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:    "OK",
+			pathId:  "1",
+			inputId: 1,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().DeleteHobby(gomock.Any(), hobbyId).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:    "Hobby with such id does not exist",
+			pathId:  "1",
+			inputId: 1,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().DeleteHobby(gomock.Any(), hobbyId).Return(MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:    "Server error",
+			pathId:  "1",
+			inputId: 1,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().DeleteHobby(gomock.Any(), hobbyId).Return(errors.New("server error"))
+			},
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppHobbies(c)
+			testCase.mockBehavior(appService, testCase.inputId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppHobbies: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("DELETE", fmt.Sprintf("/hobbies/%s", testCase.pathId), nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestGetUsersByHobbyId(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppHobbies, hobbyId int)
+
+	testTable := []struct {
+		name                string
+		pathId              string
+		inputId             int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			pathId:  "2",
+			inputId: 2,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().GetUsersByHobbyId(gomock.Any(), hobbyId).Return([]models.ResponseUser{
+					{
+						Id:          1,
+						Name:        "test name",
+						Email:       "test@email.ru",
+						Description: "test",
+						CountryId:   1,
+						Hobbies:     []int{1, 2},
+					},
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `[{"id":1,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"hobbies":[1,2]}]`,
+		},
+		{
+			name:    "Hobby with such id does not exist",
+			pathId:  "2",
+			inputId: 2,
+			mockBehavior: func(s *mockservice.MockAppHobbies, hobbyId int) {
+				s.EXPECT().GetUsersByHobbyId(gomock.Any(), hobbyId).Return(nil, MyErrors.DoesNotExist)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppHobbies(c)
+			testCase.mockBehavior(appService, testCase.inputId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppHobbies: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("GET", fmt.Sprintf("/hobbies/%s/users", testCase.pathId), nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
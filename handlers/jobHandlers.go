@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"tranee_service/MyErrors"
+)
+
+// startLoadImages starts the loading of the flags and returns 202 with the job. When the loading is already running,
+// the running job is returned with 200 instead of starting a new one.
+func (h *Handler) startLoadImages(w http.ResponseWriter, req *http.Request) {
+	job, started, err := h.service.StartLoadImages(req.Context())
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	status := http.StatusAccepted
+	if !started {
+		h.log(req).Infof("Job %s is already running", job.Id)
+		status = http.StatusOK
+	}
+	w.Header().Set("Location", "/jobs/"+job.Id)
+	h.writeJob(w, req, status, job)
+}
+
+func (h *Handler) getJob(w http.ResponseWriter, req *http.Request) {
+	job, err := h.service.GetJob(req.Context(), mux.Vars(req)["id"])
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	h.writeJob(w, req, http.StatusOK, job)
+}
+
+func (h *Handler) getJobs(w http.ResponseWriter, req *http.Request) {
+	jobs, err := h.service.GetJobs(req.Context())
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	h.writeJob(w, req, http.StatusOK, jobs)
+}
+
+// writeJob writes the jobs, they change while running, so the response is not cached.
+func (h *Handler) writeJob(w http.ResponseWriter, req *http.Request, status int, body interface{}) {
+	output, err := json.Marshal(body)
+	if err != nil {
+		h.log(req).Errorf("writeJob: error while marshaling job:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if _, err = w.Write(output); err != nil {
+		h.log(req).Errorf("writeJob: error while writing response:%s", err)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+	"tranee_service/services"
+	mockservice "tranee_service/services/mocks"
+)
+
+func TestJobs(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppJobs)
+	running := &models.Job{Id: "1a2b", Type: models.JobLoadImages, Status: models.JobRunning,
+		LoadRun: models.LoadRun{Total: 3, Checked: 1, Found: 1}}
+
+	testTable := []struct {
+		name                string
+		method              string
+		path                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedLocation    string
+		expectedRetryAfter  string
+		expectedRequestBody string
+	}{
+		{
+			name:   "Start",
+			method: "POST",
+			path:   "/jobs/load-images",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().StartLoadImages(gomock.Any()).Return(&models.Job{Id: "1a2b", Type: models.JobLoadImages,
+					Status: models.JobRunning}, true, nil)
+			},
+			expectedStatusCode: 202,
+			expectedLocation:   "/jobs/1a2b",
+			expectedRequestBody: `{"id":"1a2b","type":"load-images","status":"running","started":"0001-01-01T00:00:00Z",` +
+				`"total":0,"checked":0,"found":0,"failed":0,"skipped":0,"downloaded":0}`,
+		},
+		{
+			name:   "Already running",
+			method: "POST",
+			path:   "/jobs/load-images",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().StartLoadImages(gomock.Any()).Return(running, false, nil)
+			},
+			expectedStatusCode: 200,
+			expectedLocation:   "/jobs/1a2b",
+			expectedRequestBody: `{"id":"1a2b","type":"load-images","status":"running","started":"0001-01-01T00:00:00Z",` +
+				`"total":3,"checked":1,"found":1,"failed":0,"skipped":0,"downloaded":0}`,
+		},
+		{
+			name:   "Start is forbidden",
+			method: "POST",
+			path:   "/jobs/load-images",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().StartLoadImages(gomock.Any()).Return(nil, false, &MyErrors.ForbiddenError{Reason: MyErrors.AdminRequired})
+			},
+			expectedStatusCode: 403,
+			expectedRequestBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"only admins can do this",` +
+				`"code":"forbidden","reason":"admin_required"}`,
+		},
+		{
+			name:   "Start while shutting down",
+			method: "POST",
+			path:   "/jobs/load-images",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().StartLoadImages(gomock.Any()).Return(nil, false, fmt.Errorf("startLoadImages:%w", MyErrors.ShuttingDown))
+			},
+			expectedStatusCode:  503,
+			expectedRetryAfter:  "5",
+			expectedRequestBody: `{"type":"about:blank","title":"Service Unavailable","status":503,"detail":"the service is shutting down","code":"unavailable"}`,
+		},
+		{
+			name:   "Get",
+			method: "GET",
+			path:   "/jobs/1a2b",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().GetJob(gomock.Any(), "1a2b").Return(running, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"id":"1a2b","type":"load-images","status":"running","started":"0001-01-01T00:00:00Z",` +
+				`"total":3,"checked":1,"found":1,"failed":0,"skipped":0,"downloaded":0}`,
+		},
+		{
+			name:   "Get unknown",
+			method: "GET",
+			path:   "/jobs/3c4d",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().GetJob(gomock.Any(), "3c4d").Return(nil, MyErrors.DoesNotExist)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
+		},
+		{
+			name:   "List",
+			method: "GET",
+			path:   "/jobs",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().GetJobs(gomock.Any()).Return([]models.Job{{Id: "1a2b", Type: models.JobLoadImages,
+					Status: models.JobFailed, LoadRun: models.LoadRun{Error: "countries were not read"}}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `[{"id":"1a2b","type":"load-images","status":"failed","started":"0001-01-01T00:00:00Z",` +
+				`"total":0,"checked":0,"found":0,"failed":0,"skipped":0,"downloaded":0,"error":"countries were not read"}]`,
+		},
+		{
+			name:   "List error",
+			method: "GET",
+			path:   "/jobs",
+			mockBehavior: func(s *mockservice.MockAppJobs) {
+				s.EXPECT().GetJobs(gomock.Any()).Return(nil, errors.New("server error"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppJobs(c)
+			testCase.mockBehavior(appService)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppJobs: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest(testCase.method, testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedLocation, w.Header().Get("Location"))
+			assert.Equal(t, testCase.expectedRetryAfter, w.Header().Get("Retry-After"))
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
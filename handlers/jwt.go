@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"tranee_service/models"
+)
+
+// KeySet holds the keys the bearer tokens are signed with, by their key id. A token without a key id can be
+// checked only if there is exactly one key for its algorithm.
+type KeySet struct {
+	keys map[string]map[string]interface{}
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: map[string]map[string]interface{}{
+		jwt.SigningMethodHS256.Alg(): {},
+		jwt.SigningMethodRS256.Alg(): {},
+	}}
+}
+
+// AddHMAC adds the secret of HS256 tokens.
+func (k *KeySet) AddHMAC(id string, secret []byte) {
+	k.keys[jwt.SigningMethodHS256.Alg()][id] = secret
+}
+
+// AddRSA adds the public key of RS256 tokens.
+func (k *KeySet) AddRSA(id string, key *rsa.PublicKey) {
+	k.keys[jwt.SigningMethodRS256.Alg()][id] = key
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// LoadKeySet reads a JSON Web Key Set (RFC 7517) with "oct" keys for HS256 and "RSA" keys for RS256.
+func LoadKeySet(path string) (*KeySet, error) {
+	var file struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loadKeySet: error while reading key set:%w", err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("loadKeySet: error while decoding key set:%w", err)
+	}
+	keys := NewKeySet()
+	for _, key := range file.Keys {
+		switch key.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return nil, fmt.Errorf("loadKeySet: invalid key %q:%w", key.Kid, err)
+			}
+			keys.AddHMAC(key.Kid, secret)
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				return nil, fmt.Errorf("loadKeySet: invalid key %q:%w", key.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				return nil, fmt.Errorf("loadKeySet: invalid key %q:%w", key.Kid, err)
+			}
+			keys.AddRSA(key.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())})
+		default:
+			return nil, fmt.Errorf("loadKeySet: key %q has unsupported type %q", key.Kid, key.Kty)
+		}
+	}
+	return keys, nil
+}
+
+func (k *KeySet) key(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+	keys := k.keys[token.Method.Alg()]
+	if key, ok := keys[id]; ok {
+		return key, nil
+	}
+	if id == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key %q", id)
+}
+
+// Claims are the claims of the bearer token the service reads.
+type Claims struct {
+	Roles  []string `json:"roles"`
+	UserId int      `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// JWT authenticates the HS256 and RS256 bearer tokens signed with the keys of the key set. The token must have
+// an expiration time. The issuer and the audience are checked when they are set.
+type JWT struct {
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+}
+
+func (j *JWT) Authenticate(req *http.Request) (*models.Principal, error) {
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, ErrNoCredentials
+	}
+	var claims Claims
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}))
+	if _, err := parser.ParseWithClaims(strings.TrimPrefix(header, "Bearer "), &claims, j.Keys.key); err != nil {
+		return nil, fmt.Errorf("invalid token:%w", err)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("invalid token: expiration time is required")
+	}
+	if j.Issuer != "" && !claims.VerifyIssuer(j.Issuer, true) {
+		return nil, fmt.Errorf("invalid token: unexpected issuer %q", claims.Issuer)
+	}
+	if j.Audience != "" && !claims.VerifyAudience(j.Audience, true) {
+		return nil, errors.New("invalid token: unexpected audience")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid token: subject is required")
+	}
+	return &models.Principal{Subject: claims.Subject, Roles: claims.Roles, UserId: claims.UserId}, nil
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gorilla/mux"
+	"net/http"
+	"runtime/debug"
+	"time"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the id accepted from the client, so that it can not flood the logs.
+const maxRequestIDLength = 128
+
+// Timeouts limit the time the queries of one request can take.
+type Timeouts struct {
+	Request time.Duration
+	// Stream is used instead of Request for the responses that take long to make or to send: the chunked list
+	// of the countries and the thumbnails of the flags.
+	Stream time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{Request: 5 * time.Second, Stream: 10 * time.Second}
+}
+
+// withRequestID takes the id of the request from the X-Request-ID header or generates a new one. The id is sent
+// back to the client and added to every log line written through the logger of the request.
+func (h *Handler) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		logger := logging.With(h.logger, "request_id", id)
+		next.ServeHTTP(w, req.WithContext(logging.NewContext(req.Context(), logger)))
+	})
+}
+
+// withAccessLog writes a line for every request after it has been handled.
+func (h *Handler) withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, req)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		logger := h.log(req)
+		for _, field := range []struct {
+			key   string
+			value interface{}
+		}{
+			{"method", req.Method},
+			{"path", req.URL.Path},
+			{"status", recorder.status},
+			{"bytes", recorder.bytes},
+			{"duration", time.Since(start).String()},
+			{"remote_addr", req.RemoteAddr},
+		} {
+			logger = logging.With(logger, field.key, field.value)
+		}
+		logger.Infof("%s %s %d", req.Method, req.URL.Path, recorder.status)
+	})
+}
+
+// withRecovery turns a panic of the handler into a 500 problem instead of a dropped connection.
+func (h *Handler) withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		recorder := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			h.log(req).Errorf("Panic while handling request: %v\n%s", recovered, debug.Stack())
+			if recorder.status == 0 {
+				h.writeProblem(w, req, MyErrors.Internal, "")
+			}
+		}()
+		next.ServeHTTP(recorder, req)
+	})
+}
+
+// withTimeout sets the deadline of the request context, the repositories pass it to the data base.
+func (h *Handler) withTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		timeout := h.timeouts.Request
+		if streaming(req) {
+			timeout = h.timeouts.Stream
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// streaming reports whether the request gets one of the responses that Timeouts.Stream is used for.
+func streaming(req *http.Request) bool {
+	route := mux.CurrentRoute(req)
+	if route == nil {
+		return false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+	switch template {
+	case "/countries":
+		return req.URL.Query().Get("chunk") == "true"
+	case "/countries/{id}/flag":
+		return req.URL.Query().Get("w") != ""
+	}
+	return false
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder remembers the status code and the size of the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush passes the flush to the wrapped writer, so that the streaming handlers work through the middleware.
+func (r *responseRecorder) Flush() {
+	flusher, ok := r.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	flusher.Flush()
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+	"tranee_service/internal/metrics"
+	"tranee_service/models"
+	"tranee_service/services"
+	mockservice "tranee_service/services/mocks"
+)
+
+func TestMiddleware(t *testing.T) {
+	testTable := []struct {
+		name                string
+		requestID           string
+		handler             http.HandlerFunc
+		expectedRequestID   string
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "Request id is taken from the request",
+			requestID: "abc-123",
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte("ok"))
+			},
+			expectedRequestID:   "abc-123",
+			expectedStatusCode:  200,
+			expectedRequestBody: "ok",
+		},
+		{
+			name:      "Invalid request id is replaced",
+			requestID: "abc 123",
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedStatusCode:  204,
+			expectedRequestBody: "",
+		},
+		{
+			name:      "Panic",
+			requestID: "abc-123",
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				panic("test panic")
+			},
+			expectedRequestID:   "abc-123",
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(&services.Service{}, logging.GetLoggerLogrus(), testAuthenticator)
+			r := handler.InitRoutes()
+			r.Handle("/test", testCase.handler)
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-Request-ID", testCase.requestID)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+			if testCase.expectedRequestID != "" {
+				assert.Equal(t, testCase.expectedRequestID, w.Header().Get("X-Request-ID"))
+			} else {
+				assert.Len(t, w.Header().Get("X-Request-ID"), 32)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	handler := NewHandler(&services.Service{}, logging.GetLoggerLogrus(), testAuthenticator)
+	r := handler.InitRoutes()
+	var logger logging.Logger
+	r.HandleFunc("/test", func(w http.ResponseWriter, req *http.Request) {
+		logger = handler.log(req)
+	})
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if assert.IsType(t, &logging.Logg{}, logger) {
+		assert.Equal(t, "abc-123", logger.(*logging.Logg).Data["request_id"])
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	timeouts := Timeouts{Request: time.Hour, Stream: 2 * time.Hour}
+
+	testTable := []struct {
+		name            string
+		path            string
+		expectedTimeout time.Duration
+	}{
+		{
+			name:            "List",
+			path:            "/countries",
+			expectedTimeout: timeouts.Request,
+		},
+		{
+			name:            "Chunked list",
+			path:            "/countries?chunk=true",
+			expectedTimeout: timeouts.Stream,
+		},
+		{
+			name:            "Flag",
+			path:            "/countries/RU/flag",
+			expectedTimeout: timeouts.Request,
+		},
+		{
+			name:            "Thumbnail",
+			path:            "/countries/RU/flag?w=64",
+			expectedTimeout: timeouts.Stream,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppCountries(c)
+			var deadline time.Time
+			appService.EXPECT().GetCountries(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error) {
+					deadline, _ = ctx.Deadline()
+					return nil, models.PageInfo{}, nil
+				})
+			appService.EXPECT().GetFlag(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, id string, options models.FlagOptions) (*models.Flag, error) {
+					deadline, _ = ctx.Deadline()
+					return nil, MyErrors.FlagNotLoaded
+				})
+			handler := NewHandler(&services.Service{AppCountries: appService}, logging.GetLoggerLogrus(), testAuthenticator)
+			handler.SetTimeouts(timeouts)
+			r := handler.InitRoutes()
+
+			start := time.Now()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", testCase.path, nil))
+
+			assert.WithinDuration(t, start.Add(testCase.expectedTimeout), deadline, time.Minute)
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	appMetrics := metrics.New(nil)
+	handler := NewHandler(&services.Service{}, logging.GetLoggerLogrus(), nil)
+	r := handler.InitRoutes(appMetrics.Middleware)
+	r.HandleFunc("/test", func(w http.ResponseWriter, req *http.Request) {
+		panic("test panic")
+	}).Methods(http.MethodGet)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/hobbies/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	w := httptest.NewRecorder()
+	appMetrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, expected := range []string{
+		`tranee_http_requests_total{method="DELETE",route="/hobbies/{id}",status="401"} 1`,
+		`tranee_http_requests_total{method="GET",route="/test",status="500"} 1`,
+	} {
+		assert.Contains(t, w.Body.String(), expected)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/asaskevich/govalidator"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"tranee_service/models"
+)
+
+// parseSort parses the sort query parameter of the form "field,-field". A leading minus selects the
+// descending order. Only the fields present in allowed can be used.
+func parseSort(param string, allowed map[string]string) ([]models.SortField, error) {
+	var sort []models.SortField
+	if param == "" {
+		return nil, nil
+	}
+	for _, field := range strings.Split(param, ",") {
+		var sortField models.SortField
+		field = strings.TrimSpace(field)
+		if strings.HasPrefix(field, "-") {
+			sortField.Desc = true
+			field = strings.TrimPrefix(field, "-")
+		}
+		if _, ok := allowed[field]; !ok {
+			return nil, fmt.Errorf("field %q can not be used for sorting", field)
+		}
+		sortField.Field = field
+		sort = append(sort, sortField)
+	}
+	return sort, nil
+}
+
+// isCountryCode reports whether the id from the path can be the alpha_2 or the alpha_3 code of a country.
+func isCountryCode(id string) bool {
+	return (len(id) == 2 || len(id) == 3) && govalidator.IsAlpha(id)
+}
+
+// nextLink builds the value of the Link header that points at the next page of the cursor mode.
+func nextLink(req *http.Request, cursor string) string {
+	query := req.URL.Query()
+	query.Set("cursor", cursor)
+	return fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, query.Encode())
+}
+
+// wantsEnvelope reports whether the client asked for a list wrapped into models.Envelope, either with the
+// envelope query parameter or with the "envelope" profile of the Accept header.
+func wantsEnvelope(req *http.Request) (bool, error) {
+	if param := req.URL.Query().Get("envelope"); param != "" {
+		if param != "true" && param != "false" {
+			return false, fmt.Errorf("invalid parameter 'envelope' passed")
+		}
+		return param == "true", nil
+	}
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		if (mediaType == "application/json" || mediaType == "*/*") && params["profile"] == "envelope" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newMeta builds the pagination metadata of the envelope. The total number of rows is not counted in the cursor mode.
+func newMeta(page, limit uint64, keyset bool, info models.PageInfo) models.Meta {
+	meta := models.Meta{Limit: limit, NextCursor: info.NextCursor}
+	if !keyset {
+		meta.Page = page
+		meta.Total = &info.Total
+		meta.Pages = &info.Pages
+	}
+	return meta
+}
+
+// decodeMergePatch decodes a JSON Merge Patch (RFC 7386) into the patch struct. The resources have no optional
+// fields, so removing a field with null is rejected as well as the fields the resource does not have.
+func decodeMergePatch(req *http.Request, patch interface{}) error {
+	var fields map[string]json.RawMessage
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err == nil && mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		return fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		if string(bytes.TrimSpace(value)) == "null" {
+			return fmt.Errorf("field %q can not be removed", name)
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(patch)
+}
+
+// parseExpand parses the expand query parameter, a comma separated list of the objects embedded into a user.
+func parseExpand(param string) (models.Expand, error) {
+	var expand models.Expand
+	if param == "" {
+		return expand, nil
+	}
+	for _, field := range strings.Split(param, ",") {
+		switch strings.TrimSpace(field) {
+		case "hobbies":
+			expand.Hobbies = true
+		case "country":
+			expand.Country = true
+		default:
+			return expand, fmt.Errorf("field %q can not be expanded", field)
+		}
+	}
+	return expand, nil
+}
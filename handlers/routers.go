@@ -8,32 +8,62 @@ import (
 )
 
 type Handler struct {
-	service *services.Service
-	logger  logging.Logger
+	service       *services.Service
+	logger        logging.Logger
+	authenticator Authenticator
+	timeouts      Timeouts
 }
 
-func NewHandler(service *services.Service, logger logging.Logger) *Handler {
-	return &Handler{service: service, logger: logger}
+func NewHandler(service *services.Service, logger logging.Logger, authenticator Authenticator) *Handler {
+	return &Handler{service: service, logger: logger, authenticator: authenticator, timeouts: DefaultTimeouts()}
 }
 
-func (h *Handler) InitRoutes() *mux.Router {
+// SetTimeouts replaces the default timeouts of the requests. It is called before InitRoutes.
+func (h *Handler) SetTimeouts(timeouts Timeouts) {
+	h.timeouts = timeouts
+}
+
+func (h *Handler) log(req *http.Request) logging.Logger {
+	return logging.FromContext(req.Context(), h.logger)
+}
+
+// InitRoutes registers the routes. The outer middlewares run before the ones of the handler, so that they
+// also see the requests the handler middlewares answer themselves, like 401 and the recovered panics.
+func (h *Handler) InitRoutes(outer ...mux.MiddlewareFunc) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(outer...)
+	r.Use(h.withRequestID, h.withAccessLog, h.withRecovery, h.withAuthentication, h.withTimeout)
+	r.HandleFunc("/healthz", h.healthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", h.readyz).Methods(http.MethodGet)
+
 	r.HandleFunc("/countries/{id}", h.getOneCountry).Methods(http.MethodGet)
 	r.HandleFunc("/countries", h.getAllCountries).Methods(http.MethodGet)
 	r.HandleFunc("/countries", h.createCountry).Methods(http.MethodPost)
 	r.HandleFunc("/countries/{id}", h.changeCountry).Methods(http.MethodPut)
+	r.HandleFunc("/countries/{id}", h.patchCountry).Methods(http.MethodPatch)
 	r.HandleFunc("/countries/{id}", h.deleteCountry).Methods(http.MethodDelete)
-	r.HandleFunc("/load-images", h.loadImages).Methods(http.MethodGet)
+	r.HandleFunc("/countries/{id}/flag", h.getFlag).Methods(http.MethodGet)
 
 	r.HandleFunc("/users", h.createUser).Methods(http.MethodPost)
 	r.HandleFunc("/users", h.getUsers).Methods(http.MethodGet)
 	r.HandleFunc("/users/{id}", h.getUserById).Methods(http.MethodGet)
 	r.HandleFunc("/users/{id}", h.changeUser).Methods(http.MethodPut)
+	r.HandleFunc("/users/{id}", h.patchUser).Methods(http.MethodPatch)
 	r.HandleFunc("/users/{id}", h.deleteUser).Methods(http.MethodDelete)
 	r.HandleFunc("/users/{id}/hobbies", h.getHobbyByUserId).Methods(http.MethodGet)
+	r.HandleFunc("/users/{id}/hobbies/{hobbyId}", h.addUserHobby).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}/hobbies/{hobbyId}", h.deleteUserHobby).Methods(http.MethodDelete)
 
 	r.HandleFunc("/hobbies", h.createHobby).Methods(http.MethodPost)
 	r.HandleFunc("/hobbies", h.getHobbies).Methods(http.MethodGet)
+	r.HandleFunc("/hobbies/{id}", h.getHobbyById).Methods(http.MethodGet)
+	r.HandleFunc("/hobbies/{id}", h.changeHobby).Methods(http.MethodPut)
+	r.HandleFunc("/hobbies/{id}", h.deleteHobby).Methods(http.MethodDelete)
+	r.HandleFunc("/hobbies/{id}/users", h.getUsersByHobbyId).Methods(http.MethodGet)
+
+	r.HandleFunc("/jobs/load-images", h.startLoadImages).Methods(http.MethodPost)
+	r.HandleFunc("/jobs", h.getJobs).Methods(http.MethodGet)
+	r.HandleFunc("/jobs/{id}", h.getJob).Methods(http.MethodGet)
 
 	return r
 }
@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/asaskevich/govalidator"
+	"github.com/gorilla/mux"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,20 +16,19 @@ func (h *Handler) createUser(w http.ResponseWriter, req *http.Request) {
 	var input models.User
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
-		h.logger.Errorf("Error while decoding request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	result, err := govalidator.ValidateStruct(input)
 	if !result {
-		h.logger.Errorf("Incorrect data came from the request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
-	userId, err := h.service.AppUsers.CreateUser(&input)
+	userId, err := h.service.AppUsers.CreateUser(req.Context(), &input)
 	if err != nil {
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
 		return
 	}
 	w.Header().Set("id", strconv.Itoa(userId))
@@ -41,8 +40,8 @@ func (h *Handler) getUsers(w http.ResponseWriter, req *http.Request) {
 	if req.URL.Query().Get("page") != "" {
 		paramPage, err := strconv.Atoi(req.URL.Query().Get("page"))
 		if err != nil || paramPage < 0 {
-			h.logger.Warnf("Invalid url request:%s", err)
-			http.Error(w, "invalid url request", 400)
+			h.log(req).Warnf("Invalid url request:%s", err)
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
 			return
 		}
 		options.Page = uint64(paramPage)
@@ -50,30 +49,67 @@ func (h *Handler) getUsers(w http.ResponseWriter, req *http.Request) {
 	if req.URL.Query().Get("limit") != "" {
 		paramLimit, err := strconv.Atoi(req.URL.Query().Get("limit"))
 		if err != nil || paramLimit < 0 {
-			h.logger.Warnf("Invalid url request:%s", err)
-			http.Error(w, "invalid url request", 400)
+			h.log(req).Warnf("Invalid url request:%s", err)
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
 			return
 		}
 		options.Limit = uint64(paramLimit)
 	}
-	users, pages, err := h.service.AppUsers.GetUsers(&options)
+	sort, err := parseSort(req.URL.Query().Get("sort"), models.UserSortColumns)
 	if err != nil {
-		h.logger.Warnf("server error: %s", err)
-		http.Error(w, "server error", 500)
+		h.log(req).Warnf("Invalid parameter 'sort' passed:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid parameter 'sort' passed: %s", err))
 		return
 	}
-	output, err := json.Marshal(users)
+	options.Sort = sort
+	expand, err := parseExpand(req.URL.Query().Get("expand"))
 	if err != nil {
-		h.logger.Errorf("getUsers: error while marshaling list of users: %s", err)
-		http.Error(w, fmt.Sprintf("getUsers: error while marshaling list of users: %s", err), 500)
+		h.log(req).Warnf("Invalid parameter 'expand' passed:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid parameter 'expand' passed: %s", err))
+		return
+	}
+	options.Expand = expand
+	envelope, err := wantsEnvelope(req)
+	if err != nil {
+		h.log(req).Warnf("%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	if req.URL.Query().Has("cursor") {
+		if options.Page != 0 {
+			h.log(req).Warnf("Parameters 'page' and 'cursor' passed together")
+			h.writeProblem(w, req, MyErrors.InvalidRequest, "parameters 'page' and 'cursor' can not be used together")
+			return
+		}
+		options.Keyset = true
+		options.Cursor = req.URL.Query().Get("cursor")
+	}
+	users, page, err := h.service.AppUsers.GetUsers(req.Context(), &options)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	if page.NextCursor != "" {
+		w.Header().Set("Link", nextLink(req, page.NextCursor))
+	}
+	var body interface{} = users
+	if envelope {
+		body = models.Envelope{Data: users, Meta: newMeta(options.Page, options.Limit, options.Keyset, page)}
+	}
+	output, err := json.Marshal(body)
+	if err != nil {
+		h.log(req).Errorf("getUsers: error while marshaling list of users: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Pages", strconv.Itoa(pages))
+	if !options.Keyset {
+		w.Header().Set("Pages", strconv.Itoa(page.Pages))
+	}
 	_, err = w.Write(output)
 	if err != nil {
-		h.logger.Errorf("getUsers: error while writing response:%s", err)
-		http.Error(w, fmt.Sprintf("getUsers: error while writing response:%s", err), 500)
+		h.log(req).Errorf("getUsers: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 }
@@ -82,32 +118,32 @@ func (h *Handler) getUserById(w http.ResponseWriter, req *http.Request) {
 	paramId := strings.TrimPrefix(req.URL.Path, "/users/")
 	userId, err := strconv.Atoi(paramId)
 	if err != nil || userId <= 0 {
-		h.logger.Warnf("Invalid request:%s", err)
-		http.Error(w, "invalid url request", 400)
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
 		return
 	}
-	user, err := h.service.AppUsers.GetUserById(userId)
+	expand, err := parseExpand(req.URL.Query().Get("expand"))
 	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("getUserById: such user does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
-		}
-		h.logger.Warnf("getUserById: server error: %s", err)
-		http.Error(w, "server error", 500)
+		h.log(req).Warnf("Invalid parameter 'expand' passed:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid parameter 'expand' passed: %s", err))
+		return
+	}
+	user, err := h.service.AppUsers.GetUserById(req.Context(), userId, expand)
+	if err != nil {
+		h.writeError(w, req, err)
 		return
 	}
 	output, err := json.Marshal(user)
 	if err != nil {
-		h.logger.Errorf("getUserById: error while marshaling one user: %s", err)
-		http.Error(w, fmt.Sprintf("getUserById: error while marshaling one user: %s", err), 500)
+		h.log(req).Errorf("getUserById: error while marshaling one user: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(output)
 	if err != nil {
-		h.logger.Errorf("getUserById: error while writing response:%s", err)
-		http.Error(w, fmt.Sprintf("getUserById: error while writing response:%s", err), 500)
+		h.log(req).Errorf("getUserById: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 }
@@ -116,32 +152,54 @@ func (h *Handler) changeUser(w http.ResponseWriter, req *http.Request) {
 	var input models.User
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
-		h.logger.Errorf("Error while decoding request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	result, err := govalidator.ValidateStruct(input)
 	if !result {
-		h.logger.Errorf("Incorrect data came from the request:%s", err)
-		http.Error(w, err.Error(), 400)
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
 		return
 	}
 	paramId := strings.TrimPrefix(req.URL.Path, "/users/")
 	userId, err := strconv.Atoi(paramId)
 	if err != nil || userId <= 0 {
-		h.logger.Warnf("Invalid request:%s", err)
-		http.Error(w, fmt.Sprintf("invalid url request:%s", err), 400)
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
 		return
 	}
-	err = h.service.AppUsers.ChangeUser(&input, userId)
+	err = h.service.AppUsers.ChangeUser(req.Context(), &input, userId)
 	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("changeUser: such user does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
-		}
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) patchUser(w http.ResponseWriter, req *http.Request) {
+	var input models.UserPatch
+	if err := decodeMergePatch(req, &input); err != nil {
+		h.log(req).Errorf("Error while decoding request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	result, err := govalidator.ValidateStruct(input)
+	if !result {
+		h.log(req).Errorf("Incorrect data came from the request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, err.Error())
+		return
+	}
+	paramId := strings.TrimPrefix(req.URL.Path, "/users/")
+	userId, err := strconv.Atoi(paramId)
+	if err != nil || userId <= 0 {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
+		return
+	}
+	err = h.service.AppUsers.PatchUser(req.Context(), &input, userId)
+	if err != nil {
+		h.writeError(w, req, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -151,19 +209,13 @@ func (h *Handler) deleteUser(w http.ResponseWriter, req *http.Request) {
 	paramId := strings.TrimPrefix(req.URL.Path, "/users/")
 	userId, err := strconv.Atoi(paramId)
 	if err != nil || userId <= 0 {
-		h.logger.Warnf("Invalid request:%s", err)
-		http.Error(w, fmt.Sprintf("invalid url request:%s", err), 400)
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
 		return
 	}
-	err = h.service.AppUsers.DeleteUser(userId)
+	err = h.service.AppUsers.DeleteUser(req.Context(), userId)
 	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("deleteUser: such user does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
-		}
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+		h.writeError(w, req, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -174,32 +226,85 @@ func (h *Handler) getHobbyByUserId(w http.ResponseWriter, req *http.Request) {
 	paramId = strings.TrimSuffix(paramId, "/hobbies")
 	userId, err := strconv.Atoi(paramId)
 	if err != nil || userId <= 0 {
-		h.logger.Warnf("Invalid request:%s", err)
-		http.Error(w, "invalid url request", 400)
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid url request")
 		return
 	}
-	hobbiesId, err := h.service.AppUsers.GetHobbyByUserId(userId)
-	if err != nil {
-		if errors.Is(err, MyErrors.DoesNotExist) {
-			h.logger.Warnf("getHobbyByUserId: such user does not exist")
-			http.Error(w, MyErrors.DoesNotExist.Error(), 404)
-			return
+	expand, err := parseExpand(req.URL.Query().Get("expand"))
+	if err != nil || expand.Country {
+		h.log(req).Warnf("Invalid parameter 'expand' passed:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, "invalid parameter 'expand' passed")
+		return
+	}
+	var hobbies interface{}
+	if expand.Hobbies {
+		var user *models.ResponseUser
+		user, err = h.service.AppUsers.GetUserById(req.Context(), userId, expand)
+		if user != nil {
+			hobbies = user.ExpandedHobbies
 		}
-		h.logger.Errorf(err.Error())
-		http.Error(w, err.Error(), 500)
+	} else {
+		hobbies, err = h.service.AppUsers.GetHobbyByUserId(req.Context(), userId)
+	}
+	if err != nil {
+		h.writeError(w, req, err)
 		return
 	}
-	output, err := json.Marshal(hobbiesId)
+	output, err := json.Marshal(hobbies)
 	if err != nil {
-		h.logger.Errorf("getHobbyByUserId: error while marshaling list id of hobbies: %s", err)
-		http.Error(w, fmt.Sprintf("getHobbyByUserId: error while marshaling list id of hobbies: %s", err), 500)
+		h.log(req).Errorf("getHobbyByUserId: error while marshaling list id of hobbies: %s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(output)
 	if err != nil {
-		h.logger.Errorf("getHobbyByUserId: error while writing response:%s", err)
-		http.Error(w, fmt.Sprintf("getHobbyByUserId: error while writing response:%s", err), 500)
+		h.log(req).Errorf("getHobbyByUserId: error while writing response:%s", err)
+		h.writeProblem(w, req, MyErrors.Internal, "")
 		return
 	}
 }
+
+func (h *Handler) addUserHobby(w http.ResponseWriter, req *http.Request) {
+	userId, hobbyId, err := userHobbyIds(req)
+	if err != nil {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
+		return
+	}
+	err = h.service.AppUsers.AddUserHobby(req.Context(), userId, hobbyId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) deleteUserHobby(w http.ResponseWriter, req *http.Request) {
+	userId, hobbyId, err := userHobbyIds(req)
+	if err != nil {
+		h.log(req).Warnf("Invalid request:%s", err)
+		h.writeProblem(w, req, MyErrors.InvalidRequest, fmt.Sprintf("invalid url request:%s", err))
+		return
+	}
+	err = h.service.AppUsers.DeleteUserHobby(req.Context(), userId, hobbyId)
+	if err != nil {
+		h.writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userHobbyIds reads the ids of the user and the hobby from the path /users/{id}/hobbies/{hobbyId}.
+func userHobbyIds(req *http.Request) (int, int, error) {
+	vars := mux.Vars(req)
+	userId, err := strconv.Atoi(vars["id"])
+	if err != nil || userId <= 0 {
+		return 0, 0, fmt.Errorf("invalid user id %q", vars["id"])
+	}
+	hobbyId, err := strconv.Atoi(vars["hobbyId"])
+	if err != nil || hobbyId <= 0 {
+		return 0, 0, fmt.Errorf("invalid hobby id %q", vars["hobbyId"])
+	}
+	return userId, hobbyId, nil
+}
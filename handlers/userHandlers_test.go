@@ -7,6 +7,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
@@ -36,7 +37,7 @@ func TestCreateUser(t *testing.T) {
 				Hobbies:     []int{1, 2, 3},
 			},
 			mockBehavior: func(s *mockservice.MockAppUsers, country *models.User) {
-				s.EXPECT().CreateUser(country).Return(1, nil)
+				s.EXPECT().CreateUser(gomock.Any(), country).Return(1, nil)
 			},
 			expectedStatusCode: 201,
 		},
@@ -64,10 +65,25 @@ func TestCreateUser(t *testing.T) {
 				Hobbies:     []int{1, 2, 3},
 			},
 			mockBehavior: func(s *mockservice.MockAppUsers, country *models.User) {
-				s.EXPECT().CreateUser(country).Return(0, errors.New("server error"))
+				s.EXPECT().CreateUser(gomock.Any(), country).Return(0, errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
+		{
+			name:      "Hobbies do not exist",
+			inputBody: `{"name":"testName","email":"test@test.ru","description":"test desc","country_id":1,"hobbies":[1,2,3]}`,
+			inputUser: &models.User{
+				Name:        "testName",
+				Email:       "test@test.ru",
+				Description: "test desc",
+				CountryId:   1,
+				Hobbies:     []int{1, 2, 3},
+			},
+			mockBehavior: func(s *mockservice.MockAppUsers, country *models.User) {
+				s.EXPECT().CreateUser(gomock.Any(), country).Return(0, &MyErrors.ValidationError{Hobbies: []int{2, 3}})
+			},
+			expectedStatusCode: 422,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -78,7 +94,7 @@ func TestCreateUser(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputUser)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppUsers: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -118,7 +134,7 @@ func TestChangeUser(t *testing.T) {
 				Hobbies:     []int{1, 2, 3},
 			},
 			mockBehavior: func(s *mockservice.MockAppUsers, user *models.User, userId int) {
-				s.EXPECT().ChangeUser(user, userId).Return(nil)
+				s.EXPECT().ChangeUser(gomock.Any(), user, userId).Return(nil)
 			},
 			expectedStatusCode: 204,
 		},
@@ -144,10 +160,27 @@ func TestChangeUser(t *testing.T) {
 				Hobbies:     []int{1, 2, 3},
 			},
 			mockBehavior: func(s *mockservice.MockAppUsers, user *models.User, userId int) {
-				s.EXPECT().ChangeUser(user, userId).Return(errors.New("server error"))
+				s.EXPECT().ChangeUser(gomock.Any(), user, userId).Return(errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
+		{
+			name:      "Country does not exist",
+			pathId:    "1",
+			inputId:   1,
+			inputBody: `{"name":"testName","email":"test@test.ru","description":"test desc","country_id":7,"hobbies":[1,2,3]}`,
+			inputUser: &models.User{
+				Name:        "testName",
+				Email:       "test@test.ru",
+				Description: "test desc",
+				CountryId:   7,
+				Hobbies:     []int{1, 2, 3},
+			},
+			mockBehavior: func(s *mockservice.MockAppUsers, user *models.User, userId int) {
+				s.EXPECT().ChangeUser(gomock.Any(), user, userId).Return(&MyErrors.ValidationError{CountryId: &user.CountryId})
+			},
+			expectedStatusCode: 422,
+		},
 		{
 			name:      "User does not exist",
 			pathId:    "1",
@@ -161,7 +194,7 @@ func TestChangeUser(t *testing.T) {
 				Hobbies:     []int{1, 2, 3},
 			},
 			mockBehavior: func(s *mockservice.MockAppUsers, user *models.User, userId int) {
-				s.EXPECT().ChangeUser(user, userId).Return(MyErrors.DoesNotExist)
+				s.EXPECT().ChangeUser(gomock.Any(), user, userId).Return(MyErrors.DoesNotExist)
 			},
 			expectedStatusCode: 404,
 		},
@@ -175,7 +208,7 @@ func TestChangeUser(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputUser, testCase.inputId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppUsers: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -200,13 +233,14 @@ func TestGetUsers(t *testing.T) {
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
+		expectedLink        string
 	}{
 		{
 			name:        "OK",
 			pathQuery:   "?page=1&limit=2",
 			inputFilter: &models.Options{Page: 1, Limit: 2},
 			mockBehavior: func(s *mockservice.MockAppUsers, filter *models.Options) {
-				s.EXPECT().GetUsers(filter).Return([]models.ResponseUser{
+				s.EXPECT().GetUsers(gomock.Any(), filter).Return([]models.ResponseUser{
 					{
 						Name:        "test name",
 						Email:       "test@email.ru",
@@ -221,7 +255,7 @@ func TestGetUsers(t *testing.T) {
 						CountryId:   1,
 						Hobbies:     []int{1, 2, 3},
 					},
-				}, 1, nil)
+				}, models.PageInfo{Pages: 1}, nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `[{"id":0,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]},{"id":0,"name":"test name2","email":"test2@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]}]`,
@@ -231,7 +265,7 @@ func TestGetUsers(t *testing.T) {
 			pathQuery:   "",
 			inputFilter: &models.Options{},
 			mockBehavior: func(s *mockservice.MockAppUsers, filter *models.Options) {
-				s.EXPECT().GetUsers(filter).Return([]models.ResponseUser{
+				s.EXPECT().GetUsers(gomock.Any(), filter).Return([]models.ResponseUser{
 					{
 						Name:        "test name",
 						Email:       "test@email.ru",
@@ -246,18 +280,82 @@ func TestGetUsers(t *testing.T) {
 						CountryId:   1,
 						Hobbies:     []int{1, 2, 3},
 					},
-				}, 1, nil)
+				}, models.PageInfo{Pages: 1}, nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `[{"id":0,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]},{"id":0,"name":"test name2","email":"test2@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]}]`,
 		},
+		{
+			name:        "OK with sort",
+			pathQuery:   "?sort=name,-id",
+			inputFilter: &models.Options{Sort: []models.SortField{{Field: "name"}, {Field: "id", Desc: true}}},
+			mockBehavior: func(s *mockservice.MockAppUsers, filter *models.Options) {
+				s.EXPECT().GetUsers(gomock.Any(), filter).Return([]models.ResponseUser{
+					{
+						Name:        "test name",
+						Email:       "test@email.ru",
+						Description: "test",
+						CountryId:   1,
+						Hobbies:     []int{1, 2, 3},
+					},
+				}, models.PageInfo{Pages: 1}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `[{"id":0,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]}]`,
+		},
+		{
+			name:        "OK with cursor",
+			pathQuery:   "?limit=1&cursor=",
+			inputFilter: &models.Options{Limit: 1, Keyset: true},
+			mockBehavior: func(s *mockservice.MockAppUsers, filter *models.Options) {
+				s.EXPECT().GetUsers(gomock.Any(), filter).Return([]models.ResponseUser{
+					{
+						Id:          1,
+						Name:        "test name",
+						Email:       "test@email.ru",
+						Description: "test",
+						CountryId:   1,
+						Hobbies:     []int{1, 2, 3},
+					},
+				}, models.PageInfo{NextCursor: "next"}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `[{"id":1,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]}]`,
+			expectedLink:        `</users?cursor=next&limit=1>; rel="next"`,
+		},
+		{
+			name:        "Invalid cursor",
+			pathQuery:   "?cursor=abc",
+			inputFilter: &models.Options{Keyset: true, Cursor: "abc"},
+			mockBehavior: func(s *mockservice.MockAppUsers, filter *models.Options) {
+				s.EXPECT().GetUsers(gomock.Any(), filter).Return(nil, models.PageInfo{}, MyErrors.InvalidCursor)
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid cursor","code":"invalid_request"}`,
+		},
+		{
+			name:                "Page and cursor together",
+			pathQuery:           "?page=2&cursor=abc",
+			inputFilter:         &models.Options{},
+			mockBehavior:        func(s *mockservice.MockAppUsers, filter *models.Options) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"parameters 'page' and 'cursor' can not be used together","code":"invalid_request"}`,
+		},
+		{
+			name:                "Invalid sort",
+			pathQuery:           "?sort=password",
+			inputFilter:         &models.Options{},
+			mockBehavior:        func(s *mockservice.MockAppUsers, filter *models.Options) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid parameter 'sort' passed: field \"password\" can not be used for sorting","code":"invalid_request"}`,
+		},
 		{
 			name:                "Invalid query",
 			pathQuery:           "?page=-1&limit=2",
 			inputFilter:         &models.Options{},
 			mockBehavior:        func(s *mockservice.MockAppUsers, filter *models.Options) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:                "Invalid query2",
@@ -265,17 +363,17 @@ func TestGetUsers(t *testing.T) {
 			inputFilter:         &models.Options{},
 			mockBehavior:        func(s *mockservice.MockAppUsers, filter *models.Options) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:        "Server error",
 			pathQuery:   "?page=1&limit=2",
 			inputFilter: &models.Options{Page: 1, Limit: 2},
 			mockBehavior: func(s *mockservice.MockAppUsers, filter *models.Options) {
-				s.EXPECT().GetUsers(filter).Return(nil, 0, errors.New("server error"))
+				s.EXPECT().GetUsers(gomock.Any(), filter).Return(nil, models.PageInfo{}, errors.New("server error"))
 			},
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
 		},
 	}
 
@@ -287,7 +385,7 @@ func TestGetUsers(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputFilter)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppUsers: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -299,6 +397,7 @@ func TestGetUsers(t *testing.T) {
 
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
 			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+			assert.Equal(t, testCase.expectedLink, w.Header().Get("Link"))
 		})
 	}
 }
@@ -319,7 +418,7 @@ func TestGetUserById(t *testing.T) {
 			pathQuery: "1",
 			userId:    1,
 			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
-				s.EXPECT().GetUserById(userId).Return(&models.ResponseUser{
+				s.EXPECT().GetUserById(gomock.Any(), userId, models.Expand{}).Return(&models.ResponseUser{
 					Name:        "test name",
 					Email:       "test@email.ru",
 					Description: "test",
@@ -330,13 +429,39 @@ func TestGetUserById(t *testing.T) {
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"id":0,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"hobbies":[1,2,3]}`,
 		},
+		{
+			name:      "OK with expand",
+			pathQuery: "1?expand=hobbies,country",
+			userId:    1,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
+				s.EXPECT().GetUserById(gomock.Any(), userId, models.Expand{Hobbies: true, Country: true}).Return(&models.ResponseUser{
+					Name:            "test name",
+					Email:           "test@email.ru",
+					Description:     "test",
+					CountryId:       1,
+					Hobbies:         []int{1},
+					ExpandedHobbies: []models.ResponseHobby{{Id: 1, Name: "test hobby"}},
+					Country:         &models.Country{Name: "test country", Alpha2: "tt", Iso: 1000},
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":0,"name":"test name","email":"test@email.ru","description":"test","country_id":1,"country":{"name":"test country","full_name":"","english_name":"","alpha_2":"tt","alpha_3":"","iso":1000,"location":"","location_precise":"","url":""},"hobbies":[{"id":1,"name":"test hobby"}]}`,
+		},
+		{
+			name:                "Invalid expand",
+			pathQuery:           "1?expand=email",
+			userId:              1,
+			mockBehavior:        func(s *mockservice.MockAppUsers, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid parameter 'expand' passed: field \"email\" can not be expanded","code":"invalid_request"}`,
+		},
 		{
 			name:                "Invalid query",
 			pathQuery:           "-1",
 			userId:              1,
 			mockBehavior:        func(s *mockservice.MockAppUsers, userId int) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:                "Invalid query2",
@@ -344,27 +469,27 @@ func TestGetUserById(t *testing.T) {
 			userId:              1,
 			mockBehavior:        func(s *mockservice.MockAppUsers, userId int) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:      "Server error",
 			pathQuery: "1",
 			userId:    1,
 			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
-				s.EXPECT().GetUserById(userId).Return(nil, errors.New("server error"))
+				s.EXPECT().GetUserById(gomock.Any(), userId, models.Expand{}).Return(nil, errors.New("server error"))
 			},
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
 		},
 		{
 			name:      "Such user does not exist",
 			pathQuery: "1",
 			userId:    1,
 			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
-				s.EXPECT().GetUserById(userId).Return(nil, MyErrors.DoesNotExist)
+				s.EXPECT().GetUserById(gomock.Any(), userId, models.Expand{}).Return(nil, MyErrors.DoesNotExist)
 			},
 			expectedStatusCode:  404,
-			expectedRequestBody: "object with this id does not exist\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
 		},
 	}
 
@@ -376,7 +501,7 @@ func TestGetUserById(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.userId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppUsers: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -392,6 +517,103 @@ func TestGetUserById(t *testing.T) {
 	}
 }
 
+func TestPatchUser(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int)
+	description := "new desc"
+
+	testTable := []struct {
+		name               string
+		pathId             string
+		contentType        string
+		inputBody          string
+		inputPatch         *models.UserPatch
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:        "OK",
+			pathId:      "1",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"description":"new desc"}`,
+			inputPatch:  &models.UserPatch{Description: &description},
+			mockBehavior: func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int) {
+				s.EXPECT().PatchUser(gomock.Any(), patch, userId).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Unsupported content type",
+			pathId:             "1",
+			contentType:        "text/plain",
+			inputBody:          `{"description":"new desc"}`,
+			mockBehavior:       func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Incorrect email",
+			pathId:             "1",
+			contentType:        "application/merge-patch+json",
+			inputBody:          `{"email":"test"}`,
+			mockBehavior:       func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Incorrect user id",
+			pathId:             "a",
+			contentType:        "application/merge-patch+json",
+			inputBody:          `{"description":"new desc"}`,
+			mockBehavior:       func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:        "User with such id does not exist",
+			pathId:      "1",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"description":"new desc"}`,
+			inputPatch:  &models.UserPatch{Description: &description},
+			mockBehavior: func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int) {
+				s.EXPECT().PatchUser(gomock.Any(), patch, userId).Return(MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:        "Hobbies do not exist",
+			pathId:      "1",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"hobbies":[4]}`,
+			inputPatch:  &models.UserPatch{Hobbies: &[]int{4}},
+			mockBehavior: func(s *mockservice.MockAppUsers, patch *models.UserPatch, userId int) {
+				s.EXPECT().PatchUser(gomock.Any(), patch, userId).Return(&MyErrors.ValidationError{Hobbies: []int{4}})
+			},
+			expectedStatusCode: 422,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppUsers(c)
+			userId, _ := strconv.Atoi(testCase.pathId)
+			testCase.mockBehavior(appService, testCase.inputPatch, userId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppUsers: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("PATCH", fmt.Sprintf("/users/%s", testCase.pathId), bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set("Content-Type", testCase.contentType)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	type mockBehavior func(s *mockservice.MockAppUsers, userId int)
 
@@ -407,7 +629,7 @@ func TestDeleteUser(t *testing.T) {
 			pathQuery: "1",
 			userId:    1,
 			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
-				s.EXPECT().DeleteUser(userId).Return(nil)
+				s.EXPECT().DeleteUser(gomock.Any(), userId).Return(nil)
 			},
 			expectedStatusCode: 204,
 		},
@@ -430,7 +652,7 @@ func TestDeleteUser(t *testing.T) {
 			pathQuery: "1",
 			userId:    1,
 			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
-				s.EXPECT().DeleteUser(userId).Return(errors.New("server error"))
+				s.EXPECT().DeleteUser(gomock.Any(), userId).Return(errors.New("server error"))
 			},
 			expectedStatusCode: 500,
 		},
@@ -439,7 +661,7 @@ func TestDeleteUser(t *testing.T) {
 			pathQuery: "1",
 			userId:    1,
 			mockBehavior: func(s *mockservice.MockAppUsers, userId int) {
-				s.EXPECT().DeleteUser(userId).Return(MyErrors.DoesNotExist)
+				s.EXPECT().DeleteUser(gomock.Any(), userId).Return(MyErrors.DoesNotExist)
 			},
 			expectedStatusCode: 404,
 		},
@@ -453,7 +675,7 @@ func TestDeleteUser(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.userId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppUsers: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -484,7 +706,7 @@ func TestGetHobbyByUserId(t *testing.T) {
 			pathQuery: "1",
 			inputId:   1,
 			mockBehavior: func(s *mockservice.MockAppUsers, id int) {
-				s.EXPECT().GetHobbyByUserId(id).Return([]int{1, 2}, nil)
+				s.EXPECT().GetHobbyByUserId(gomock.Any(), id).Return([]int{1, 2}, nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `[1,2]`,
@@ -495,7 +717,7 @@ func TestGetHobbyByUserId(t *testing.T) {
 			inputId:             0,
 			mockBehavior:        func(s *mockservice.MockAppUsers, id int) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:                "Invalid query2",
@@ -503,27 +725,27 @@ func TestGetHobbyByUserId(t *testing.T) {
 			inputId:             0,
 			mockBehavior:        func(s *mockservice.MockAppUsers, id int) {},
 			expectedStatusCode:  400,
-			expectedRequestBody: "invalid url request\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid url request","code":"invalid_request"}`,
 		},
 		{
 			name:      "Such user does not exist",
 			pathQuery: "1",
 			inputId:   1,
 			mockBehavior: func(s *mockservice.MockAppUsers, id int) {
-				s.EXPECT().GetHobbyByUserId(id).Return(nil, MyErrors.DoesNotExist)
+				s.EXPECT().GetHobbyByUserId(gomock.Any(), id).Return(nil, MyErrors.DoesNotExist)
 			},
 			expectedStatusCode:  404,
-			expectedRequestBody: "object with this id does not exist\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"object with this id does not exist","code":"not_found"}`,
 		},
 		{
 			name:      "Server error",
 			pathQuery: "1",
 			inputId:   1,
 			mockBehavior: func(s *mockservice.MockAppUsers, id int) {
-				s.EXPECT().GetHobbyByUserId(id).Return(nil, errors.New("server error"))
+				s.EXPECT().GetHobbyByUserId(gomock.Any(), id).Return(nil, errors.New("server error"))
 			},
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal"}`,
 		},
 	}
 
@@ -535,7 +757,7 @@ func TestGetHobbyByUserId(t *testing.T) {
 			testCase.mockBehavior(appService, testCase.inputId)
 			logger := logging.GetLoggerLogrus()
 			serv := &services.Service{AppUsers: appService}
-			handler := NewHandler(serv, logger)
+			handler := NewHandler(serv, logger, testAuthenticator)
 
 			r := handler.InitRoutes()
 
@@ -550,3 +772,157 @@ func TestGetHobbyByUserId(t *testing.T) {
 		})
 	}
 }
+
+func TestAddUserHobby(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppUsers, userId, hobbyId int)
+
+	testTable := []struct {
+		name               string
+		path               string
+		userId             int
+		hobbyId            int
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:    "OK",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().AddUserHobby(gomock.Any(), userId, hobbyId).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Invalid hobby id",
+			path:               "/users/1/hobbies/a",
+			mockBehavior:       func(s *mockservice.MockAppUsers, userId, hobbyId int) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:    "User or hobby does not exist",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().AddUserHobby(gomock.Any(), userId, hobbyId).Return(MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:    "Server error",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().AddUserHobby(gomock.Any(), userId, hobbyId).Return(errors.New("server error"))
+			},
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppUsers(c)
+			testCase.mockBehavior(appService, testCase.userId, testCase.hobbyId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppUsers: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("POST", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestDeleteUserHobby(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockAppUsers, userId, hobbyId int)
+
+	testTable := []struct {
+		name               string
+		path               string
+		userId             int
+		hobbyId            int
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:    "OK",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().DeleteUserHobby(gomock.Any(), userId, hobbyId).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Invalid user id",
+			path:               "/users/0/hobbies/2",
+			mockBehavior:       func(s *mockservice.MockAppUsers, userId, hobbyId int) {},
+			expectedStatusCode: 400,
+		},
+		{
+			name:    "User does not have this hobby",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().DeleteUserHobby(gomock.Any(), userId, hobbyId).Return(MyErrors.DoesNotExist)
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:    "Last hobby of the user",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().DeleteUserHobby(gomock.Any(), userId, hobbyId).Return(MyErrors.LastHobby)
+			},
+			expectedStatusCode: 409,
+		},
+		{
+			name:    "Server error",
+			path:    "/users/1/hobbies/2",
+			userId:  1,
+			hobbyId: 2,
+			mockBehavior: func(s *mockservice.MockAppUsers, userId, hobbyId int) {
+				s.EXPECT().DeleteUserHobby(gomock.Any(), userId, hobbyId).Return(errors.New("server error"))
+			},
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appService := mockservice.NewMockAppUsers(c)
+			testCase.mockBehavior(appService, testCase.userId, testCase.hobbyId)
+			logger := logging.GetLoggerLogrus()
+			serv := &services.Service{AppUsers: appService}
+			handler := NewHandler(serv, logger, testAuthenticator)
+
+			r := handler.InitRoutes()
+
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest("DELETE", testCase.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned for the keys that have nothing saved.
+var ErrNotFound = errors.New("blob does not exist")
+
+// Info describes the saved content and where it came from.
+type Info struct {
+	ContentType string    `json:"content_type"`
+	Hash        string    `json:"hash"`
+	Size        int64     `json:"size"`
+	Updated     time.Time `json:"updated"`
+	// Source is the address the content was downloaded from, SourceETag is the ETag the source sent with it.
+	Source     string `json:"source,omitempty"`
+	SourceETag string `json:"source_etag,omitempty"`
+}
+
+// Store keeps the contents by keys. The keys are paths of the form "flags/RU".
+type Store interface {
+	Stat(ctx context.Context, key string) (Info, error)
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, Info, error)
+	// Put saves the content, the hash, the size and the time of the update in info are set by the store.
+	Put(ctx context.Context, key string, content []byte, info Info) (Info, error)
+}
+
+// Hash returns the hash the stores identify the content with.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStore keeps every content in a file of the directory, its info is kept next to it in a ".json" file.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("newFileStore: error while creating directory:%w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of the key. The keys that could point outside of the directory are rejected.
+func (s *FileStore) path(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || strings.HasPrefix(key, "..") || strings.HasSuffix(key, ".json") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s *FileStore) Stat(ctx context.Context, key string) (Info, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+	return readInfo(file)
+}
+
+func (s *FileStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, Info, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	info, err := readInfo(file)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	content, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Info{}, fmt.Errorf("open: error while opening %s:%w", key, err)
+	}
+	return content, info, nil
+}
+
+// Put writes the content before its info, both through temporary files, so that a reader never gets a partly
+// written file.
+func (s *FileStore) Put(ctx context.Context, key string, content []byte, info Info) (Info, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+	info.Hash = Hash(content)
+	info.Size = int64(len(content))
+	info.Updated = time.Now().UTC()
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		return Info{}, fmt.Errorf("put: error while marshaling info of %s:%w", key, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return Info{}, fmt.Errorf("put: error while creating directory of %s:%w", key, err)
+	}
+	if err := writeFile(file, content); err != nil {
+		return Info{}, fmt.Errorf("put: error while writing %s:%w", key, err)
+	}
+	if err := writeFile(file+".json", encoded); err != nil {
+		return Info{}, fmt.Errorf("put: error while writing info of %s:%w", key, err)
+	}
+	return info, nil
+}
+
+func readInfo(file string) (Info, error) {
+	var info Info
+	encoded, err := os.ReadFile(file + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return info, ErrNotFound
+	}
+	if err != nil {
+		return info, fmt.Errorf("readInfo: error while reading %s:%w", file, err)
+	}
+	if err := json.Unmarshal(encoded, &info); err != nil {
+		return info, fmt.Errorf("readInfo: error while decoding %s:%w", file, err)
+	}
+	return info, nil
+}
+
+// writeFile replaces the file at once by renaming a temporary file written next to it.
+func writeFile(file string, content []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), file)
+}
@@ -0,0 +1,47 @@
+package blobstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	_, err = store.Stat(ctx, "flags/RU")
+	assert.Equal(t, ErrNotFound, err)
+
+	saved, err := store.Put(ctx, "flags/RU", []byte("<svg/>"), Info{ContentType: "image/svg+xml", Source: "https://example.com/ru.svg"})
+	assert.NoError(t, err)
+	assert.Equal(t, Hash([]byte("<svg/>")), saved.Hash)
+	assert.Equal(t, int64(6), saved.Size)
+
+	content, info, err := store.Open(ctx, "flags/RU")
+	if assert.NoError(t, err) {
+		defer content.Close()
+		data, err := io.ReadAll(content)
+		assert.NoError(t, err)
+		assert.Equal(t, "<svg/>", string(data))
+		assert.Equal(t, "image/svg+xml", info.ContentType)
+		assert.Equal(t, saved.Hash, info.Hash)
+		assert.Equal(t, "https://example.com/ru.svg", info.Source)
+	}
+}
+
+func TestFileStoreKeys(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../flags", "flags/../../x", "flags//RU", "flags/RU.json"} {
+		_, err := store.Put(context.Background(), key, []byte("image"), Info{})
+		assert.Error(t, err, key)
+	}
+}
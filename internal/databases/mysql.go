@@ -16,7 +16,8 @@ type MysqlDB struct {
 }
 
 func NewMysqlDB(database *MysqlDB) (*sql.DB, error) {
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s",
+	// The strict mode makes MySQL report the values that do not fit into the columns instead of truncating them.
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?sql_mode=%%27TRADITIONAL%%27",
 		database.Username, database.Password, database.Host, database.Port, database.DBName))
 	if err != nil {
 		log.Panicf("Database open error:%s", err)
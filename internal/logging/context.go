@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"context"
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries the logger, so that the log lines of a request can be correlated.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or fallback if there is none.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if logger, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// With returns a logger that adds the field to every line. Loggers of unknown types are returned as they are.
+func With(logger Logger, key string, value interface{}) Logger {
+	switch l := logger.(type) {
+	case *zap.SugaredLogger:
+		return l.With(key, value)
+	case *Logg:
+		return &Logg{l.WithField(key, value)}
+	}
+	return logger
+}
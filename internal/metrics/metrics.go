@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+	"tranee_service/models"
+)
+
+const namespace = "tranee"
+
+// Metrics collects the metrics of the service into its own registry, so that they can be read in tests
+// without a Prometheus server.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	flagsChecked    prometheus.Counter
+	flagsFound      prometheus.Counter
+	flagsFailed     prometheus.Counter
+	flagsDownloaded prometheus.Counter
+}
+
+// New creates the metrics. The statistics of the connection pool are read from db if it is not nil.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route template, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Duration of the repository methods.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repository", "method"}),
+		flagsChecked: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "flag_loader_countries_checked_total",
+			Help:      "Number of countries the flag loader looked for.",
+		}),
+		flagsFound: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "flag_loader_flags_found_total",
+			Help:      "Number of flags the flag loader found.",
+		}),
+		flagsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "flag_loader_failures_total",
+			Help:      "Number of countries whose flag was not found or not downloaded because of an error.",
+		}),
+		flagsDownloaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "flag_loader_images_downloaded_total",
+			Help:      "Number of changed images of the flags the flag loader saved.",
+		}),
+	}
+	m.registry.MustRegister(m.requests, m.requestDuration, m.queryDuration, m.flagsChecked, m.flagsFound, m.flagsFailed,
+		m.flagsDownloaded)
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+	}
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts the requests by the template of the matched route, so that the ids in the path do not
+// produce a series per object.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		route := "unknown"
+		if current := mux.CurrentRoute(req); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		status := strconv.Itoa(recorder.status)
+		m.requests.WithLabelValues(route, req.Method, status).Inc()
+		m.requestDuration.WithLabelValues(route, req.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+func (m *Metrics) ObserveQuery(repository, method string, duration time.Duration) {
+	m.queryDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveLoad(run models.LoadRun) {
+	m.flagsChecked.Add(float64(run.Checked))
+	m.flagsFound.Add(float64(run.Found))
+	m.flagsFailed.Add(float64(run.Failed))
+	m.flagsDownloaded.Add(float64(run.Downloaded))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes the flush to the wrapped writer, so that the streaming handlers work through the middleware.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"tranee_service/models"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New(nil)
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/countries/{id}", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["id"] == "XX" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods(http.MethodGet)
+
+	for _, path := range []string{"/countries/RU", "/countries/BY", "/countries/XX"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.requests.WithLabelValues("/countries/{id}", "GET", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requests.WithLabelValues("/countries/{id}", "GET", "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.requestDuration))
+}
+
+func TestObserve(t *testing.T) {
+	m := New(nil)
+
+	m.ObserveQuery("country", "GetOneCountry", 10*time.Millisecond)
+	m.ObserveLoad(models.LoadRun{Checked: 5, Found: 3, Failed: 2, Downloaded: 3})
+	m.ObserveLoad(models.LoadRun{Checked: 5, Found: 4, Failed: 1, Downloaded: 1})
+
+	assert.Equal(t, 1, testutil.CollectAndCount(m.queryDuration))
+	expected := `
+# HELP tranee_flag_loader_countries_checked_total Number of countries the flag loader looked for.
+# TYPE tranee_flag_loader_countries_checked_total counter
+tranee_flag_loader_countries_checked_total 10
+# HELP tranee_flag_loader_flags_found_total Number of flags the flag loader found.
+# TYPE tranee_flag_loader_flags_found_total counter
+tranee_flag_loader_flags_found_total 7
+# HELP tranee_flag_loader_images_downloaded_total Number of changed images of the flags the flag loader saved.
+# TYPE tranee_flag_loader_images_downloaded_total counter
+tranee_flag_loader_images_downloaded_total 4
+# HELP tranee_flag_loader_failures_total Number of countries whose flag was not found or not downloaded because of an error.
+# TYPE tranee_flag_loader_failures_total counter
+tranee_flag_loader_failures_total 3
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected),
+		"tranee_flag_loader_countries_checked_total", "tranee_flag_loader_flags_found_total", "tranee_flag_loader_images_downloaded_total",
+		"tranee_flag_loader_failures_total")
+	assert.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	m := New(nil)
+	m.ObserveQuery("hobby", "GetHobbies", time.Millisecond)
+	w := httptest.NewRecorder()
+
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `tranee_repository_query_duration_seconds_count{method="GetHobbies",repository="hobby"} 1`)
+}
+
+func TestMiddlewareFlush(t *testing.T) {
+	m := New(nil)
+	r := mux.NewRouter()
+	r.Use(m.Middleware)
+	r.HandleFunc("/countries", func(w http.ResponseWriter, req *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !assert.True(t, ok) {
+			return
+		}
+		w.Write([]byte("chunk\n"))
+		flusher.Flush()
+	}).Methods(http.MethodGet)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/countries", nil))
+
+	assert.True(t, w.Flushed)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requests.WithLabelValues("/countries", "GET", "200")))
+}
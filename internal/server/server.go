@@ -10,14 +10,19 @@ type Server struct {
 	httpServer *http.Server
 }
 
-func (s *Server) Run(host, port string, handler http.Handler) error {
-	s.httpServer = &http.Server{
+// NewServer prepares the server, so that it can be shut down even if Run has not been called yet.
+// writeTimeout limits the writing of one response, it is longer than the timeouts of the handlers.
+func NewServer(host, port string, handler http.Handler, writeTimeout time.Duration) *Server {
+	return &Server{httpServer: &http.Server{
 		Addr:           host + ":" + port,
 		Handler:        handler,
 		MaxHeaderBytes: 1 << 20, //1 Mb
 		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
-	}
+		WriteTimeout:   writeTimeout,
+	}}
+}
+
+func (s *Server) Run() error {
 	return s.httpServer.ListenAndServe()
 }
 
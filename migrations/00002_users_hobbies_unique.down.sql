@@ -0,0 +1 @@
+ALTER TABLE users_hobbies ADD INDEX users_hobbies_user_id (user_id), DROP INDEX users_hobbies_user_hobby;
\ No newline at end of file
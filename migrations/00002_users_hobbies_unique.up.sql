@@ -0,0 +1,5 @@
+DELETE duplicate FROM users_hobbies duplicate
+    JOIN users_hobbies original ON duplicate.user_id = original.user_id
+    AND duplicate.hobby_id = original.hobby_id AND duplicate.id > original.id;
+
+ALTER TABLE users_hobbies ADD UNIQUE KEY users_hobbies_user_hobby (user_id, hobby_id);
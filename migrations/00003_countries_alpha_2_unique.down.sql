@@ -0,0 +1 @@
+ALTER TABLE countries DROP INDEX alpha_2;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Fails with "Duplicate entry" when countries share alpha_2. They are not merged here, since the users of the
+-- removed countries could not be restored by the down migration: see scripts/merge_countries_alpha_2.sql.
+ALTER TABLE countries ADD UNIQUE KEY alpha_2 (alpha_2);
@@ -0,0 +1,9 @@
+package models
+
+// Principal is the caller identified by the credentials of the request.
+type Principal struct {
+	Subject string
+	Roles   []string
+	// UserId is the id of the user record the caller owns, 0 if there is none.
+	UserId int
+}
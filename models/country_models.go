@@ -1,5 +1,34 @@
 package models
 
+import (
+	"encoding/json"
+	"github.com/asaskevich/govalidator"
+	"io"
+	"net/url"
+	"time"
+)
+
+func init() {
+	govalidator.TagMap["flagurl"] = validFlagURL
+}
+
+// validFlagURL is the "flagurl" validator of the url of the country. The images are downloaded from the url,
+// so it is an http or https url, or the file url a local flag provider returned. The service checks that
+// the file is in the directories of the providers.
+func validFlagURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return parsed.Host != ""
+	case "file":
+		return parsed.Path != ""
+	}
+	return false
+}
+
 type Country struct {
 	Name            string `json:"name"`
 	FullName        string `json:"full_name"`
@@ -12,22 +41,109 @@ type Country struct {
 	Url             string `json:"url"`
 }
 
+// Flag is the saved image of the flag of a country. Hash identifies the content.
+type Flag struct {
+	Content     io.ReadSeekCloser
+	ContentType string
+	Hash        string
+	Updated     time.Time
+}
+
+// FlagOptions selects the image of the flag: the thumbnail of Width pixels when it is set, and the SVG original
+// instead of the thumbnail when the client accepts SVG.
+type FlagOptions struct {
+	Width     int
+	AcceptSVG bool
+}
+
 type ResponseCountry struct {
 	Name            string `json:"name" valid:"required"`
 	FullName        string `json:"full_name"`
 	EnglishName     string `json:"english_name" valid:"required"`
-	Alpha2          string `json:"alpha_2"  valid:"stringlength(2|2)"`
-	Alpha3          string `json:"alpha_3"  valid:"stringlength(3|3)"`
-	Iso             int    `json:"iso" valid:"required"`
+	Alpha2          string `json:"alpha_2"  valid:"required,matches(^[A-Z]{2}$)"`
+	Alpha3          string `json:"alpha_3"  valid:"required,matches(^[A-Z]{3}$),codepair~alpha_3 does not belong to the country of alpha_2"`
+	Iso             int    `json:"iso" valid:"required,range(1|999)"`
 	Location        string `json:"location" `
 	LocationPrecise string `json:"location_precise"`
-	Url             string `json:"url"`
+	Url             string `json:"url" valid:"flagurl~url must be an http(s) or file url"`
+}
+
+// CountryPatch is the body of a JSON Merge Patch for a country: only the fields that were sent are not nil.
+type CountryPatch struct {
+	Name            *string `json:"name" valid:"stringlength(1|100)"`
+	FullName        *string `json:"full_name"`
+	EnglishName     *string `json:"english_name" valid:"stringlength(1|150)"`
+	Alpha2          *string `json:"alpha_2" valid:"matches(^[A-Z]{2}$)"`
+	Alpha3          *string `json:"alpha_3" valid:"matches(^[A-Z]{3}$),codepair~alpha_3 does not belong to the country of alpha_2"`
+	Iso             *int    `json:"iso" valid:"range(1|999)"`
+	Location        *string `json:"location"`
+	LocationPrecise *string `json:"location_precise"`
+	Url             *string `json:"url" valid:"flagurl~url must be an http(s) or file url"`
 }
 
 type Filters struct {
-	Page  uint64
-	Limit uint64
-	Flag  bool
+	Page            uint64
+	Limit           uint64
+	Flag            bool
+	Name            string
+	EnglishName     string
+	Location        string
+	LocationPrecise string
+	IsoFrom         int
+	IsoTo           int
+	Sort            []SortField
+	Keyset          bool
+	Cursor          string
+}
+
+// PageInfo describes the page returned by the list methods: the number of rows and pages in the page/limit mode
+// or the cursor of the next page in the cursor mode.
+type PageInfo struct {
+	Total      int
+	Pages      int
+	NextCursor string
+}
+
+// Envelope wraps a list response together with its pagination metadata.
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta Meta        `json:"meta"`
+}
+
+// Meta is the pagination metadata of the Envelope. Total and Pages are not known in the cursor mode, they are
+// sent as null then.
+type Meta struct {
+	Page       uint64 `json:"page,omitempty"`
+	Limit      uint64 `json:"limit,omitempty"`
+	Total      *int   `json:"total"`
+	Pages      *int   `json:"pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SortField is one entry of the sort query parameter: the JSON name of the field and its direction.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// CountrySortColumns maps the country fields allowed in the sort parameter to their columns.
+var CountrySortColumns = map[string]string{
+	"name":             "name",
+	"full_name":        "full_name",
+	"english_name":     "english_name",
+	"alpha_2":          "alpha_2",
+	"alpha_3":          "alpha_3",
+	"iso":              "iso",
+	"location":         "location",
+	"location_precise": "location_precise",
+}
+
+// UserSortColumns maps the user fields allowed in the sort parameter to their columns.
+var UserSortColumns = map[string]string{
+	"id":         "users.id",
+	"name":       "users.name",
+	"email":      "users.email",
+	"country_id": "users.country_id",
 }
 
 type User struct {
@@ -38,18 +154,51 @@ type User struct {
 	Hobbies     []int  `json:"hobbies"`
 }
 
+// UserPatch is the body of a JSON Merge Patch for a user: only the fields that were sent are not nil.
+type UserPatch struct {
+	Name        *string `json:"name" valid:"alpha"`
+	Email       *string `json:"email" valid:"email"`
+	Description *string `json:"description"`
+	CountryId   *int    `json:"country_id"`
+	Hobbies     *[]int  `json:"hobbies"`
+}
+
 type ResponseUser struct {
-	Id          int    `json:"id"`
-	Name        string `json:"name"`
-	Email       string `json:"email"`
-	Description string `json:"description"`
-	CountryId   int    `json:"country_id"`
-	Hobbies     []int  `json:"hobbies"`
+	Id              int             `json:"id"`
+	Name            string          `json:"name"`
+	Email           string          `json:"email"`
+	Description     string          `json:"description"`
+	CountryId       int             `json:"country_id"`
+	Hobbies         []int           `json:"hobbies"`
+	ExpandedHobbies []ResponseHobby `json:"-"`
+	Country         *Country        `json:"country,omitempty"`
+}
+
+// MarshalJSON replaces the hobby ids with the hobby objects when the hobbies were expanded.
+func (u ResponseUser) MarshalJSON() ([]byte, error) {
+	type user ResponseUser
+	if u.ExpandedHobbies == nil {
+		return json.Marshal(user(u))
+	}
+	return json.Marshal(struct {
+		user
+		Hobbies []ResponseHobby `json:"hobbies"`
+	}{user: user(u), Hobbies: u.ExpandedHobbies})
+}
+
+// Expand lists the related objects that are embedded into the user.
+type Expand struct {
+	Hobbies bool
+	Country bool
 }
 
 type Options struct {
-	Page  uint64
-	Limit uint64
+	Page   uint64
+	Limit  uint64
+	Sort   []SortField
+	Keyset bool
+	Cursor string
+	Expand Expand
 }
 
 type Hobby struct {
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// The statuses of the health checks.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Health is the state of the service returned by the health endpoints.
+type Health struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+// Check is the state of one dependency of the service.
+type Check struct {
+	Status  string   `json:"status"`
+	Detail  string   `json:"detail,omitempty"`
+	Version *uint    `json:"version,omitempty"`
+	LastRun *LoadRun `json:"last_run,omitempty"`
+}
+
+// LoadRun is the result of one loading of the images of the countries.
+type LoadRun struct {
+	Started    time.Time   `json:"started"`
+	Finished   *time.Time  `json:"finished,omitempty"`
+	Total      int         `json:"total"`
+	Checked    int         `json:"checked"`
+	Found      int         `json:"found"`
+	Failed     int         `json:"failed"`
+	Skipped    int         `json:"skipped"`
+	Downloaded int         `json:"downloaded"`
+	Error      string      `json:"error,omitempty"`
+	Report     *LoadReport `json:"report,omitempty"`
+}
+
+// LoadReport lists the countries by the result of the looking for their flags.
+type LoadReport struct {
+	Succeeded []string        `json:"succeeded"`
+	Failed    []CountryReason `json:"failed"`
+	Skipped   []CountryReason `json:"skipped"`
+}
+
+// CountryReason tells why the flag of the country was not loaded.
+type CountryReason struct {
+	Alpha2 string `json:"alpha_2"`
+	Reason string `json:"reason"`
+}
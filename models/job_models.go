@@ -0,0 +1,21 @@
+package models
+
+// The statuses of the jobs.
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// JobLoadImages is the type of the job that loads the flags of the countries.
+const JobLoadImages = "load-images"
+
+// Job is a background task started through the API. The progress of the loading of the flags is reported
+// in the fields of the run.
+type Job struct {
+	Id     string `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	LoadRun
+}
@@ -0,0 +1,21 @@
+package models
+
+import "tranee_service/MyErrors"
+
+// Problem is the body of an error response, "application/problem+json" of RFC 7807.
+type Problem struct {
+	Type          string          `json:"type"`
+	Title         string          `json:"title"`
+	Status        int             `json:"status"`
+	Detail        string          `json:"detail,omitempty"`
+	Code          MyErrors.Code   `json:"code"`
+	InvalidParams []InvalidParam  `json:"invalid_params,omitempty"`
+	Reason        MyErrors.Reason `json:"reason,omitempty"`
+}
+
+// InvalidParam is a field of the request that refers to an object that does not exist or repeats a unique value.
+type InvalidParam struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value,omitempty"`
+	Reason string      `json:"reason"`
+}
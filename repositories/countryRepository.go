@@ -1,10 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
+	"strings"
 	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
@@ -19,19 +21,23 @@ func NewCountryRepository(db *sql.DB, logger logging.Logger) *CountryRepository
 	return &CountryRepository{db: db, logger: logger}
 }
 
-func (c *CountryRepository) SaveInitialCountries(countries []models.Country) error {
+func (c *CountryRepository) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, c.logger)
+}
+
+func (c *CountryRepository) SaveInitialCountries(ctx context.Context, countries []models.Country) error {
 	var numberRows int
-	transaction, err := c.db.Begin()
+	transaction, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		c.logger.Errorf("SaveInitialCountries: can not starts transaction:%s", err)
+		c.log(ctx).Errorf("SaveInitialCountries: can not starts transaction:%s", err)
 		return fmt.Errorf("saveInitialCountries: can not starts transaction:%w", err)
 	}
 	defer transaction.Rollback()
 	query := `SELECT COUNT(*) FROM countries`
-	row := transaction.QueryRow(query)
+	row := transaction.QueryRowContext(ctx, query)
 	if err := row.Scan(&numberRows); err != nil {
-		c.logger.Errorf("Error while scanning for numberRows:%s", err)
-		return fmt.Errorf("error while scanning for numberRows:%s", err)
+		c.log(ctx).Errorf("Error while scanning for numberRows:%s", err)
+		return fmt.Errorf("error while scanning for numberRows:%w", err)
 	}
 	if numberRows == 0 {
 		query = `INSERT INTO countries (name, full_name, english_name, alpha_2, alpha_3, iso, location, location_precise, url) values `
@@ -41,150 +47,375 @@ func (c *CountryRepository) SaveInitialCountries(countries []models.Country) err
 			query += `(?,?,?,?,?,?,?,?,?),`
 		}
 		query = query[:len(query)-1] // remove the trailing comma
-		_, err = transaction.Exec(query, values...)
+		_, err = transaction.ExecContext(ctx, query, values...)
 		if err != nil {
-			c.logger.Errorf("SaveInitialCountries: error while insert countries:%s", err)
+			c.log(ctx).Errorf("SaveInitialCountries: error while insert countries:%s", err)
 			return fmt.Errorf("saveInitialCountries: error while insert countriesr:%w", err)
 		}
 	}
 	return transaction.Commit()
 }
 
-func (c *CountryRepository) GetOneCountry(id string) (*models.Country, error) {
+func (c *CountryRepository) GetOneCountry(ctx context.Context, id string) (*models.Country, error) {
 	var country models.Country
-	query := "SELECT name, full_name, english_name, alpha_2, alpha_3, iso, location, location_precise, url FROM countries WHERE alpha_2 = ? OR alpha_3 = ?"
-	row := c.db.QueryRow(query, id, id)
+	query := "SELECT name, full_name, english_name, alpha_2, alpha_3, iso, location, location_precise, url FROM countries WHERE " + codeColumn(id) + " = ?"
+	row := c.db.QueryRowContext(ctx, query, id)
 	if err := row.Scan(&country.Name, &country.FullName, &country.EnglishName, &country.Alpha2, &country.Alpha3, &country.Iso, &country.Location, &country.LocationPrecise, &country.Url); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			c.logger.Errorf("GetOneCountry:object with this id does not exist")
+			c.log(ctx).Errorf("GetOneCountry:object with this id does not exist")
 			return nil, errors.Wrap(MyErrors.DoesNotExist, "getOneCountry")
 		} else {
-			c.logger.Errorf("Error while scanning for country:%s", err)
+			c.log(ctx).Errorf("Error while scanning for country:%s", err)
 			return nil, fmt.Errorf("getOneCountry: Error while scanning for country:%w", err)
 		}
 	}
 	return &country, nil
 }
 
-func (c *CountryRepository) GetCountries(filters *models.Filters) ([]models.Country, int, error) {
+func (c *CountryRepository) GetCountries(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error) {
 	var countries []models.Country
-	var pages int
-	var sel squirrel.SelectBuilder
-	s := squirrel.Select("name", "full_name", "english_name", "alpha_2", "alpha_3", "iso", "location", "location_precise", "url").From("countries")
-	if filters.Flag {
-		sel = s.Where(squirrel.Eq{"url": ""})
+	var page models.PageInfo
+	where := countryConditions(filters)
+	sort := withTiebreaker(filters.Sort, "alpha_2")
+	limit := filters.Limit
+	if filters.Keyset && limit == 0 {
+		limit = defaultCursorLimit
 	}
-	if filters.Page != 0 && filters.Limit != 0 {
-		sel = s.Limit(filters.Limit).Offset((filters.Page - 1) * filters.Limit).OrderBy("alpha_2")
-	} else {
-		sel = s
-		pages = 1
+	sel := squirrel.Select("name", "full_name", "english_name", "alpha_2", "alpha_3", "iso", "location", "location_precise", "url").From("countries")
+	if len(where) != 0 {
+		sel = sel.Where(where)
+	}
+	switch {
+	case filters.Keyset:
+		values, err := decodeCursor(filters.Cursor, sort)
+		if err != nil {
+			c.log(ctx).Errorf("GetCountries: %s", err)
+			return nil, page, errors.Wrap(MyErrors.InvalidCursor, "getCountries")
+		}
+		if values != nil {
+			after, err := keysetCondition(sort, models.CountrySortColumns, values)
+			if err != nil {
+				c.log(ctx).Errorf("GetCountries: %s", err)
+				return nil, page, fmt.Errorf("getCountries: %w", err)
+			}
+			sel = sel.Where(after)
+		}
+		sel = sel.Limit(limit + 1)
+	case filters.Page != 0 && filters.Limit != 0:
+		sel = sel.Limit(filters.Limit).Offset((filters.Page - 1) * filters.Limit)
+	default:
+		page.Pages = 1
+	}
+	if page.Pages != 1 || len(filters.Sort) != 0 {
+		order, err := orderBy(sort, models.CountrySortColumns)
+		if err != nil {
+			c.log(ctx).Errorf("GetCountries: %s", err)
+			return nil, page, fmt.Errorf("getCountries: %w", err)
+		}
+		sel = sel.OrderBy(order...)
 	}
 	query, args, err := sel.ToSql()
 	if err != nil {
-		c.logger.Errorf("GetCountries: can not builds the query into a SQL:%s", err)
-		return nil, 0, fmt.Errorf("getCountries: can not builds the query into a SQL:%s", err)
+		c.log(ctx).Errorf("GetCountries: can not builds the query into a SQL:%s", err)
+		return nil, page, fmt.Errorf("getCountries: can not builds the query into a SQL:%w", err)
 	}
-	rows, err := c.db.Query(query, args...)
+	rows, err := c.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		c.logger.Errorf("GetCountries: can not executes a query:%s", err)
-		return nil, 0, fmt.Errorf("getCountries: can not executes a query:%s", err)
+		c.log(ctx).Errorf("GetCountries: can not executes a query:%s", err)
+		return nil, page, fmt.Errorf("getCountries: can not executes a query:%w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var country models.Country
 		if err := rows.Scan(&country.Name, &country.FullName, &country.EnglishName, &country.Alpha2, &country.Alpha3, &country.Iso, &country.Location, &country.LocationPrecise, &country.Url); err != nil {
-			c.logger.Errorf("Error while scanning for country:%s", err)
-			return nil, 0, fmt.Errorf("getCountries:repository error:%w", err)
+			c.log(ctx).Errorf("Error while scanning for country:%s", err)
+			return nil, page, fmt.Errorf("getCountries:repository error:%w", err)
 		}
 		countries = append(countries, country)
 	}
-	if pages != 1 {
-		query = "SELECT CEILING(COUNT(*)/?) FROM countries"
-		row := c.db.QueryRow(query, filters.Limit)
-		if err := row.Scan(&pages); err != nil {
-			c.logger.Errorf("Error while scanning for pages:%s", err)
-			return nil, 0, fmt.Errorf("error while scanning for pages:%s", err)
+	if filters.Keyset {
+		if uint64(len(countries)) > limit {
+			countries = countries[:limit]
+			last := countries[len(countries)-1]
+			var values []interface{}
+			for _, field := range sort {
+				values = append(values, countryField(last, field.Field))
+			}
+			page.NextCursor, err = encodeCursor(sort, values)
+			if err != nil {
+				c.log(ctx).Errorf("GetCountries: %s", err)
+				return nil, page, fmt.Errorf("getCountries: %w", err)
+			}
 		}
+		return countries, page, nil
+	}
+	if page.Pages == 1 {
+		page.Total = len(countries)
+		return countries, page, nil
+	}
+	count := squirrel.Select("COUNT(*)").From("countries")
+	if len(where) != 0 {
+		count = count.Where(where)
+	}
+	query, args, err = count.ToSql()
+	if err != nil {
+		c.log(ctx).Errorf("GetCountries: can not builds the query into a SQL:%s", err)
+		return nil, page, fmt.Errorf("getCountries: can not builds the query into a SQL:%w", err)
 	}
-	return countries, pages, nil
+	row := c.db.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(&page.Total); err != nil {
+		c.log(ctx).Errorf("Error while scanning for total:%s", err)
+		return nil, page, fmt.Errorf("error while scanning for total:%w", err)
+	}
+	page.Pages = (page.Total + int(filters.Limit) - 1) / int(filters.Limit)
+	return countries, page, nil
 }
 
-func (c *CountryRepository) CreateCountry(country *models.ResponseCountry) (string, error) {
+// countryField returns the value of the country field with the given JSON name.
+func countryField(country models.Country, field string) interface{} {
+	switch field {
+	case "name":
+		return country.Name
+	case "full_name":
+		return country.FullName
+	case "english_name":
+		return country.EnglishName
+	case "alpha_2":
+		return country.Alpha2
+	case "alpha_3":
+		return country.Alpha3
+	case "iso":
+		return country.Iso
+	case "location":
+		return country.Location
+	case "location_precise":
+		return country.LocationPrecise
+	}
+	return nil
+}
+
+// countryConditions converts the filters into the WHERE clause shared by the list and the pages queries.
+func countryConditions(filters *models.Filters) squirrel.And {
+	where := squirrel.And{}
+	if filters.Flag {
+		where = append(where, squirrel.Eq{"url": ""})
+	}
+	if filters.Name != "" {
+		where = append(where, squirrel.Like{"name": likePattern(filters.Name)})
+	}
+	if filters.EnglishName != "" {
+		where = append(where, squirrel.Like{"english_name": likePattern(filters.EnglishName)})
+	}
+	if filters.Location != "" {
+		where = append(where, squirrel.Eq{"location": filters.Location})
+	}
+	if filters.LocationPrecise != "" {
+		where = append(where, squirrel.Eq{"location_precise": filters.LocationPrecise})
+	}
+	if filters.IsoFrom != 0 {
+		where = append(where, squirrel.GtOrEq{"iso": filters.IsoFrom})
+	}
+	if filters.IsoTo != 0 {
+		where = append(where, squirrel.LtOrEq{"iso": filters.IsoTo})
+	}
+	return where
+}
+
+// likePattern builds a "contains" pattern for LIKE, escaping the wildcard characters of the search string.
+func likePattern(search string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + replacer.Replace(search) + "%"
+}
+
+func (c *CountryRepository) CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error) {
 	var id string
 	query := "INSERT INTO countries (name, full_name, english_name, alpha_2, alpha_3, iso, location, location_precise, url) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
-	result, err := c.db.Exec(query, country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url)
+	result, err := c.db.ExecContext(ctx, query, country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url)
 	if err != nil {
-		c.logger.Errorf("CreateCountry: can not adding new country:%s", err)
-		return "", fmt.Errorf("createCountry: can not adding new country:%w", err)
+		c.log(ctx).Errorf("CreateCountry: can not adding new country:%s", err)
+		return "", fmt.Errorf("createCountry: can not adding new country:%w", constraintError(err))
 	}
 	insertId, err := result.LastInsertId()
 	if err != nil {
-		c.logger.Errorf("CreateCountry: error while getting insertId:%s", err)
+		c.log(ctx).Errorf("CreateCountry: error while getting insertId:%s", err)
 		return "", fmt.Errorf("createCountry: error while getting insertId:%w", err)
 	}
 	query = "SELECT alpha_2 FROM countries WHERE id = ?"
-	row := c.db.QueryRow(query, insertId)
+	row := c.db.QueryRowContext(ctx, query, insertId)
 	if err = row.Scan(&id); err != nil {
-		c.logger.Errorf("CreateCountry: error while scanning for countryId:%s", err)
+		c.log(ctx).Errorf("CreateCountry: error while scanning for countryId:%s", err)
 		return "", fmt.Errorf("createCountry: error while scanning for countryId:%w", err)
 	}
 	return id, nil
 }
 
-func (c *CountryRepository) ChangeCountry(country *models.ResponseCountry, countryId string) error {
-	query := "UPDATE IGNORE countries SET name = ?, full_name = ?, english_name = ?, alpha_2 = ?, alpha_3 = ?, iso = ?, location = ?, location_precise = ?, url = ? WHERE alpha_2 = ? OR alpha_3 = ?"
-	result, err := c.db.Exec(query, country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url, countryId, countryId)
+// ChangeCountry replaces all the fields of the country and returns its new alpha_2 if the update changes it.
+func (c *CountryRepository) ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error) {
+	var id int
+	var alpha2 string
+	transaction, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		c.logger.Errorf("ChangeCountry: error while updating country:%s", err)
-		return fmt.Errorf("changeCountry: error while updating country:%w", err)
+		c.log(ctx).Errorf("ChangeCountry: can not starts transaction:%s", err)
+		return "", fmt.Errorf("changeCountry: can not starts transaction:%w", err)
 	}
-	numberRows, err := result.RowsAffected()
+	defer transaction.Rollback()
+	query := "SELECT id, alpha_2 FROM countries WHERE " + codeColumn(countryId) + " = ? FOR UPDATE"
+	row := transaction.QueryRowContext(ctx, query, countryId)
+	if err := row.Scan(&id, &alpha2); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.log(ctx).Errorf("ChangeCountry:object with this id does not exist")
+			return "", errors.Wrap(MyErrors.DoesNotExist, "changeCountry")
+		}
+		c.log(ctx).Errorf("ChangeCountry: error while scanning for country:%s", err)
+		return "", fmt.Errorf("changeCountry: error while scanning for country:%w", err)
+	}
+	query = "UPDATE countries SET name = ?, full_name = ?, english_name = ?, alpha_2 = ?, alpha_3 = ?, iso = ?, location = ?, location_precise = ?, url = ? WHERE id = ?"
+	_, err = transaction.ExecContext(ctx, query, country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url, id)
 	if err != nil {
-		c.logger.Errorf("ChangeCountry: error while getting rows affected:%s", err)
-		return fmt.Errorf("changeCountry: error while getting rows affected:%w", err)
+		c.log(ctx).Errorf("ChangeCountry: error while updating country:%s", err)
+		return "", fmt.Errorf("changeCountry: error while updating country:%w", constraintError(err))
 	}
-	if numberRows == 0 {
-		c.logger.Errorf("ChangeCountry:object with this id does not exist")
-		return errors.Wrap(MyErrors.DoesNotExist, "changeCountry")
+	if err = transaction.Commit(); err != nil {
+		c.log(ctx).Errorf("ChangeCountry: error while committing transaction:%s", err)
+		return "", fmt.Errorf("changeCountry: error while committing transaction:%w", err)
 	}
-	return nil
+	if country.Alpha2 != alpha2 {
+		return country.Alpha2, nil
+	}
+	return "", nil
 }
 
-func (c *CountryRepository) DeleteCountry(countryId string) error {
-	query := "DELETE FROM countries WHERE alpha_2 = ? OR alpha_3 = ?"
-	result, err := c.db.Exec(query, countryId, countryId)
+// PatchCountry changes the fields that were sent in the patch. The new alpha_2 is returned when it has changed,
+// since the country is found by it. The codes are checked after they are merged with the stored ones, the patch
+// alone does not show the pair when only one of them is sent.
+func (c *CountryRepository) PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error) {
+	var id int
+	var alpha2, alpha3 string
+	transaction, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		c.logger.Errorf("Error while scanning for countryId:%s", err)
-		return fmt.Errorf("deleteCountry: error while scanning for countryId:%s", err)
+		c.log(ctx).Errorf("PatchCountry: can not starts transaction:%s", err)
+		return "", fmt.Errorf("patchCountry: can not starts transaction:%w", err)
+	}
+	defer transaction.Rollback()
+	query := "SELECT id, alpha_2, alpha_3 FROM countries WHERE " + codeColumn(countryId) + " = ? FOR UPDATE"
+	row := transaction.QueryRowContext(ctx, query, countryId)
+	if err := row.Scan(&id, &alpha2, &alpha3); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.log(ctx).Errorf("PatchCountry:object with this id does not exist")
+			return "", errors.Wrap(MyErrors.DoesNotExist, "patchCountry")
+		}
+		c.log(ctx).Errorf("PatchCountry: error while scanning for country:%s", err)
+		return "", fmt.Errorf("patchCountry: error while scanning for country:%w", err)
+	}
+	newAlpha2, newAlpha3 := alpha2, alpha3
+	if patch.Alpha2 != nil {
+		newAlpha2 = *patch.Alpha2
+	}
+	if patch.Alpha3 != nil {
+		newAlpha3 = *patch.Alpha3
+	}
+	if !models.ConsistentCodes(newAlpha2, newAlpha3) {
+		c.log(ctx).Errorf("PatchCountry: alpha_3 %s does not belong to the country of alpha_2 %s", newAlpha3, newAlpha2)
+		return "", fmt.Errorf("patchCountry:%w", &MyErrors.ValidationError{CodePair: true})
+	}
+	set := countryPatchColumns(patch)
+	if len(set) != 0 {
+		query, args, err := squirrel.Update("countries").SetMap(set).Where(squirrel.Eq{"id": id}).ToSql()
+		if err != nil {
+			c.log(ctx).Errorf("PatchCountry: can not builds the query into a SQL:%s", err)
+			return "", fmt.Errorf("patchCountry: can not builds the query into a SQL:%w", err)
+		}
+		if _, err = transaction.ExecContext(ctx, query, args...); err != nil {
+			c.log(ctx).Errorf("PatchCountry: error while updating country:%s", err)
+			return "", fmt.Errorf("patchCountry: error while updating country:%w", constraintError(err))
+		}
+	}
+	if err = transaction.Commit(); err != nil {
+		c.log(ctx).Errorf("PatchCountry: error while committing transaction:%s", err)
+		return "", fmt.Errorf("patchCountry: error while committing transaction:%w", err)
+	}
+	if patch.Alpha2 != nil && *patch.Alpha2 != alpha2 {
+		return *patch.Alpha2, nil
+	}
+	return "", nil
+}
+
+// countryPatchColumns returns the columns of the fields that were sent in the patch with their new values.
+func countryPatchColumns(patch *models.CountryPatch) map[string]interface{} {
+	set := make(map[string]interface{})
+	if patch.Name != nil {
+		set["name"] = *patch.Name
+	}
+	if patch.FullName != nil {
+		set["full_name"] = *patch.FullName
+	}
+	if patch.EnglishName != nil {
+		set["english_name"] = *patch.EnglishName
+	}
+	if patch.Alpha2 != nil {
+		set["alpha_2"] = *patch.Alpha2
+	}
+	if patch.Alpha3 != nil {
+		set["alpha_3"] = *patch.Alpha3
+	}
+	if patch.Iso != nil {
+		set["iso"] = *patch.Iso
+	}
+	if patch.Location != nil {
+		set["location"] = *patch.Location
+	}
+	if patch.LocationPrecise != nil {
+		set["location_precise"] = *patch.LocationPrecise
+	}
+	if patch.Url != nil {
+		set["url"] = *patch.Url
+	}
+	return set
+}
+
+func (c *CountryRepository) DeleteCountry(ctx context.Context, countryId string) error {
+	query := "DELETE FROM countries WHERE " + codeColumn(countryId) + " = ?"
+	result, err := c.db.ExecContext(ctx, query, countryId)
+	if err != nil {
+		c.log(ctx).Errorf("Error while scanning for countryId:%s", err)
+		return fmt.Errorf("deleteCountry: error while scanning for countryId:%w", err)
 	}
 	numberRows, err := result.RowsAffected()
 	if err != nil {
-		c.logger.Errorf("Error while getting number affected rows:%s", err)
-		return fmt.Errorf("deleteCountry: error while getting number affected rows:%s", err)
+		c.log(ctx).Errorf("Error while getting number affected rows:%s", err)
+		return fmt.Errorf("deleteCountry: error while getting number affected rows:%w", err)
 	}
 	if numberRows == 0 {
-		c.logger.Errorf("DeleteCountry:object with this id does not exist")
+		c.log(ctx).Errorf("DeleteCountry:object with this id does not exist")
 		return errors.Wrap(MyErrors.DoesNotExist, "deleteCountry")
 	}
 	return nil
 }
 
-func (c *CountryRepository) CheckCountryId(countryId string) error {
+func (c *CountryRepository) CheckCountryId(ctx context.Context, countryId string) error {
 	var exist bool
-	query := "SELECT EXISTS (select 1 from countries where alpha_2 = ? OR alpha_3 = ?)"
-	row := c.db.QueryRow(query, countryId, countryId)
+	query := "SELECT EXISTS (select 1 from countries where " + codeColumn(countryId) + " = ?)"
+	row := c.db.QueryRowContext(ctx, query, countryId)
 	if err := row.Scan(&exist); err != nil {
-		c.logger.Errorf("Error while scanning for existing country:%s", err)
-		return err
+		c.log(ctx).Errorf("CheckCountryId: error while scanning for existing country:%s", err)
+		return fmt.Errorf("checkCountryId: error while scanning for existing country:%w", err)
 	}
 	if !exist {
-		return fmt.Errorf("such a country does not exist")
+		c.log(ctx).Errorf("CheckCountryId:object with this id does not exist")
+		return errors.Wrap(MyErrors.DoesNotExist, "checkCountryId")
 	}
 	return nil
 }
 
-func (c *CountryRepository) LoadImages(countries []models.Country) error {
+// codeColumn returns the column that identifies the country by the code: alpha_2 for two letters and alpha_3 for three.
+func codeColumn(countryId string) string {
+	if len(countryId) == 2 {
+		return "alpha_2"
+	}
+	return "alpha_3"
+}
+
+func (c *CountryRepository) LoadImages(ctx context.Context, countries []models.Country) error {
 	query := `UPDATE countries SET url = CASE english_name `
 	query2 := " "
 	var values []interface{}
@@ -200,9 +431,9 @@ func (c *CountryRepository) LoadImages(countries []models.Country) error {
 		values = append(values, value)
 	}
 
-	_, err := c.db.Exec(query, values...)
+	_, err := c.db.ExecContext(ctx, query, values...)
 	if err != nil {
-		c.logger.Errorf("LoadImages: error while insert flag url:%s", err)
+		c.log(ctx).Errorf("LoadImages: error while insert flag url:%s", err)
 		return fmt.Errorf("loadImages: error while insert flag url:%w", err)
 	}
 	return nil
@@ -1,11 +1,14 @@
 package repositories
 
 import (
+	"context"
 	"database/sql/driver"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 )
@@ -132,7 +135,7 @@ func TestSaveInitialCountries(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputCountries)
-			err := r.SaveInitialCountries(tt.inputCountries)
+			err := r.SaveInitialCountries(context.Background(), tt.inputCountries)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -192,7 +195,7 @@ func TestGetOneCountry(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputId)
-			country, err := r.GetOneCountry(tt.inputId)
+			country, err := r.GetOneCountry(context.Background(), tt.inputId)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -232,8 +235,8 @@ func TestGetCountries(t *testing.T) {
 					AddRow("test name", "test full name", "test english name", "tt", "ttt", 1000, "test location", "test location precise", "").
 					AddRow("test name2", "test full name2", "test english name2", "tp", "tpt", 1001, "test location", "test location precise", "")
 				mock.ExpectQuery("SELECT name, full_name,").WillReturnRows(rows)
-				rows = sqlmock.NewRows([]string{"pages"}).AddRow(1)
-				mock.ExpectQuery("SELECT CEILING").WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"total"}).AddRow(2)
+				mock.ExpectQuery("SELECT COUNT").WillReturnRows(rows)
 			},
 			expectedResult: []models.Country{
 				{
@@ -312,8 +315,8 @@ func TestGetCountries(t *testing.T) {
 					AddRow("test name", "test full name", "test english name", "tt", "ttt", 1000, "test location", "test location precise", "").
 					AddRow("test name2", "test full name2", "test english name2", "tp", "tpt", 1001, "test location", "test location precise", "")
 				mock.ExpectQuery("SELECT name, full_name,").WillReturnRows(rows)
-				rows = sqlmock.NewRows([]string{"pages"}).AddRow(1)
-				mock.ExpectQuery("SELECT CEILING").WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"total"}).AddRow(2)
+				mock.ExpectQuery("SELECT COUNT").WillReturnRows(rows)
 			},
 			expectedResult: []models.Country{
 				{
@@ -341,6 +344,41 @@ func TestGetCountries(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "OK with filters",
+			inputFilter: &models.Filters{
+				Page:     1,
+				Limit:    2,
+				Name:     "te_st",
+				Location: "test location",
+				IsoFrom:  900,
+				IsoTo:    1100,
+				Sort:     []models.SortField{{Field: "iso", Desc: true}},
+			},
+			mock: func(filter *models.Filters) {
+				rows := sqlmock.NewRows([]string{"name", "full_name", "english_name", "alpha_2", "alpha_3", "iso", "location", "location_precise", "url"}).
+					AddRow("test name", "test full name", "test english name", "tt", "ttt", 1000, "test location", "test location precise", "")
+				mock.ExpectQuery(`SELECT name, full_name, .* FROM countries WHERE \(name LIKE \? AND location = \? AND iso >= \? AND iso <= \?\) ORDER BY iso DESC, alpha_2 LIMIT 2 OFFSET 0`).
+					WithArgs(`%te\_st%`, "test location", 900, 1100).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"total"}).AddRow(2)
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM countries WHERE`).
+					WithArgs(`%te\_st%`, "test location", 900, 1100).WillReturnRows(rows)
+			},
+			expectedResult: []models.Country{
+				{
+					Name:            "test name",
+					FullName:        "test full name",
+					EnglishName:     "test english name",
+					Alpha2:          "tt",
+					Alpha3:          "ttt",
+					Iso:             1000,
+					Location:        "test location",
+					LocationPrecise: "test location precise",
+					Url:             "",
+				},
+			},
+			expectedError: false,
+		},
 		{
 			name: "Data base error",
 			inputFilter: &models.Filters{
@@ -357,7 +395,7 @@ func TestGetCountries(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputFilter)
-			countries, _, err := r.GetCountries(tt.inputFilter)
+			countries, _, err := r.GetCountries(context.Background(), tt.inputFilter)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -379,11 +417,12 @@ func TestCreateCountry(t *testing.T) {
 	r := NewRepository(db, logger)
 
 	testTable := []struct {
-		name           string
-		mock           func(country *models.ResponseCountry)
-		inputCountry   *models.ResponseCountry
-		expectedResult string
-		expectedError  bool
+		name             string
+		mock             func(country *models.ResponseCountry)
+		inputCountry     *models.ResponseCountry
+		expectedResult   string
+		expectedError    bool
+		expectedConflict string
 	}{
 		{
 			name: "OK",
@@ -430,13 +469,40 @@ func TestCreateCountry(t *testing.T) {
 			expectedResult: "",
 			expectedError:  true,
 		},
+		{
+			name: "Country with such alpha_3 already exists",
+			inputCountry: &models.ResponseCountry{
+				Name:            "test name",
+				FullName:        "test full name",
+				EnglishName:     "test english name",
+				Alpha2:          "tt",
+				Alpha3:          "ttt",
+				Iso:             1000,
+				Location:        "test location",
+				LocationPrecise: "test location precise",
+				Url:             "test url",
+			},
+			mock: func(country *models.ResponseCountry) {
+				mock.ExpectExec("INSERT INTO countries").
+					WithArgs(country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url).
+					WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'ttt' for key 'alpha_3'"})
+			},
+			expectedResult:   "",
+			expectedError:    true,
+			expectedConflict: "alpha_3",
+		},
 	}
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputCountry)
-			id, err := r.CreateCountry(tt.inputCountry)
+			id, err := r.CreateCountry(context.Background(), tt.inputCountry)
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedConflict != "" {
+					var conflict *MyErrors.ConflictError
+					assert.ErrorAs(t, err, &conflict)
+					assert.Equal(t, tt.expectedConflict, conflict.Field)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedResult, id)
@@ -454,66 +520,246 @@ func TestChangeCountry(t *testing.T) {
 	}
 	defer db.Close()
 	r := NewRepository(db, logger)
+	country := &models.ResponseCountry{
+		Name:            "test name",
+		FullName:        "test full name",
+		EnglishName:     "test english name",
+		Alpha2:          "TT",
+		Alpha3:          "TTT",
+		Iso:             100,
+		Location:        "test location",
+		LocationPrecise: "test location precise",
+		Url:             "test url",
+	}
+	expectUpdate := func(country *models.ResponseCountry) *sqlmock.ExpectedExec {
+		return mock.ExpectExec(`UPDATE countries SET (.+) WHERE id = \?`).
+			WithArgs(country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url, 1)
+	}
 
 	testTable := []struct {
-		name          string
-		mock          func(country *models.ResponseCountry, countryId string)
-		inputCountry  *models.ResponseCountry
-		inputId       string
-		expectedError bool
+		name             string
+		mock             func(country *models.ResponseCountry, countryId string)
+		inputCountry     *models.ResponseCountry
+		inputId          string
+		expectedResult   string
+		expectedError    error
+		expectedConflict string
+		expectedTooLong  []string
 	}{
 		{
-			name: "OK",
-			inputCountry: &models.ResponseCountry{
-				Name:            "test name",
-				FullName:        "test full name",
-				EnglishName:     "test english name",
-				Alpha2:          "tt",
-				Alpha3:          "ttt",
-				Iso:             1000,
-				Location:        "test location",
-				LocationPrecise: "test location precise",
-				Url:             "test url",
+			name:         "OK",
+			inputCountry: country,
+			inputId:      "TT",
+			mock: func(country *models.ResponseCountry, countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2"}).AddRow(1, "TT")
+				mock.ExpectQuery("SELECT id, alpha_2 FROM countries (.+) FOR UPDATE").WithArgs(countryId).WillReturnRows(rows)
+				expectUpdate(country).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
 			},
-			inputId: "TT",
+			expectedResult: "",
+		},
+		{
+			name:         "OK alpha_2 changed",
+			inputCountry: country,
+			inputId:      "OLD",
 			mock: func(country *models.ResponseCountry, countryId string) {
-				result := sqlmock.NewResult(1, 1)
-				mock.ExpectExec("UPDATE IGNORE countries").
-					WithArgs(country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url, countryId, countryId).
-					WillReturnResult(result)
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2"}).AddRow(1, "OL")
+				mock.ExpectQuery("SELECT id, alpha_2 FROM countries (.+) FOR UPDATE").WithArgs(countryId).WillReturnRows(rows)
+				expectUpdate(country).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			},
-			expectedError: false,
+			expectedResult: "TT",
 		},
 		{
-			name: "Data base error",
-			inputCountry: &models.ResponseCountry{
-				Name:            "test name",
-				FullName:        "test full name",
-				EnglishName:     "test english name",
-				Alpha2:          "tt",
-				Alpha3:          "ttt",
-				Iso:             1000,
-				Location:        "test location",
-				LocationPrecise: "test location precise",
-				Url:             "test url",
+			name:         "Country with such id does not exist",
+			inputCountry: country,
+			inputId:      "TT",
+			mock: func(country *models.ResponseCountry, countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2"})
+				mock.ExpectQuery("SELECT id, alpha_2 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectRollback()
 			},
-			inputId: "TT",
+			expectedError: MyErrors.DoesNotExist,
+		},
+		{
+			name:         "Another country has such alpha_3",
+			inputCountry: country,
+			inputId:      "TT",
 			mock: func(country *models.ResponseCountry, countryId string) {
-				mock.ExpectExec("UPDATE IGNORE countries").
-					WithArgs(country.Name, country.FullName, country.EnglishName, country.Alpha2, country.Alpha3, country.Iso, country.Location, country.LocationPrecise, country.Url, countryId, countryId).
-					WillReturnError(errors.New("data base error"))
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2"}).AddRow(1, "TT")
+				mock.ExpectQuery("SELECT id, alpha_2 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				expectUpdate(country).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'TTT' for key 'countries.alpha_3'"})
+				mock.ExpectRollback()
 			},
-			expectedError: true,
+			expectedConflict: "alpha_3",
+		},
+		{
+			name:         "Value does not fit into the column",
+			inputCountry: country,
+			inputId:      "TT",
+			mock: func(country *models.ResponseCountry, countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2"}).AddRow(1, "TT")
+				mock.ExpectQuery("SELECT id, alpha_2 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				expectUpdate(country).WillReturnError(&mysql.MySQLError{Number: 1406, Message: "Data too long for column 'location' at row 1"})
+				mock.ExpectRollback()
+			},
+			expectedTooLong: []string{"location"},
+		},
+		{
+			name:         "Data base error",
+			inputCountry: country,
+			inputId:      "TT",
+			mock: func(country *models.ResponseCountry, countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2"}).AddRow(1, "TT")
+				mock.ExpectQuery("SELECT id, alpha_2 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				expectUpdate(country).WillReturnError(errors.New("data base error"))
+				mock.ExpectRollback()
+			},
+			expectedError: errors.New("data base error"),
 		},
 	}
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputCountry, tt.inputId)
-			err := r.ChangeCountry(tt.inputCountry, tt.inputId)
+			newId, err := r.ChangeCountry(context.Background(), tt.inputCountry, tt.inputId)
+			switch {
+			case tt.expectedConflict != "":
+				var conflict *MyErrors.ConflictError
+				assert.ErrorAs(t, err, &conflict)
+				assert.Equal(t, tt.expectedConflict, conflict.Field)
+			case tt.expectedTooLong != nil:
+				var validation *MyErrors.ValidationError
+				assert.ErrorAs(t, err, &validation)
+				assert.Equal(t, tt.expectedTooLong, validation.Truncated)
+			case tt.expectedError == MyErrors.DoesNotExist:
+				assert.ErrorIs(t, err, MyErrors.DoesNotExist)
+			case tt.expectedError != nil:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, newId)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPatchCountry(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+	fullName := "test full name"
+	iso := 1000
+	alpha2 := "XB"
+	mismatched := "FR"
+
+	testTable := []struct {
+		name             string
+		mock             func(countryId string)
+		inputPatch       *models.CountryPatch
+		inputId          string
+		expectedId       string
+		expectedError    bool
+		expectedCodePair bool
+	}{
+		{
+			name:       "OK",
+			inputPatch: &models.CountryPatch{FullName: &fullName, Iso: &iso},
+			inputId:    "TT",
+			mock: func(countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2", "alpha_3"}).AddRow(1, "TT", "TTO")
+				mock.ExpectQuery("SELECT id, alpha_2, alpha_3 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectExec(`UPDATE countries SET full_name = \?, iso = \? WHERE id = \?`).
+					WithArgs(fullName, iso, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:       "OK with new alpha_2",
+			inputPatch: &models.CountryPatch{Alpha2: &alpha2},
+			inputId:    "XA",
+			mock: func(countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2", "alpha_3"}).AddRow(1, "XA", "XAA")
+				mock.ExpectQuery("SELECT id, alpha_2, alpha_3 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectExec(`UPDATE countries SET alpha_2 = \? WHERE id = \?`).
+					WithArgs(alpha2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedId: "XB",
+		},
+		{
+			name:       "OK empty patch",
+			inputPatch: &models.CountryPatch{},
+			inputId:    "TT",
+			mock: func(countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2", "alpha_3"}).AddRow(1, "TT", "TTO")
+				mock.ExpectQuery("SELECT id, alpha_2, alpha_3 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:       "alpha_2 of another country",
+			inputPatch: &models.CountryPatch{Alpha2: &mismatched},
+			inputId:    "TT",
+			mock: func(countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2", "alpha_3"}).AddRow(1, "TT", "TTO")
+				mock.ExpectQuery("SELECT id, alpha_2, alpha_3 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedError:    true,
+			expectedCodePair: true,
+		},
+		{
+			name:       "Country with such id does not exist",
+			inputPatch: &models.CountryPatch{FullName: &fullName},
+			inputId:    "TT",
+			mock: func(countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2", "alpha_3"})
+				mock.ExpectQuery("SELECT id, alpha_2, alpha_3 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+		{
+			name:       "Data base error",
+			inputPatch: &models.CountryPatch{FullName: &fullName},
+			inputId:    "TT",
+			mock: func(countryId string) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id", "alpha_2", "alpha_3"}).AddRow(1, "TT", "TTO")
+				mock.ExpectQuery("SELECT id, alpha_2, alpha_3 FROM countries").WithArgs(countryId).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE countries SET").WillReturnError(errors.New("data base error"))
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.inputId)
+			id, err := r.PatchCountry(context.Background(), tt.inputPatch, tt.inputId)
 			if tt.expectedError {
+				var validation *MyErrors.ValidationError
 				assert.Error(t, err)
+				assert.Equal(t, tt.expectedCodePair, errors.As(err, &validation) && validation.CodePair)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedId, id)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
@@ -540,7 +786,7 @@ func TestDeleteCountry(t *testing.T) {
 			inputId: "TT",
 			mock: func(countryId string) {
 				result := sqlmock.NewResult(1, 1)
-				mock.ExpectExec("DELETE FROM countries").WithArgs(countryId, countryId).WillReturnResult(result)
+				mock.ExpectExec("DELETE FROM countries").WithArgs(countryId).WillReturnResult(result)
 			},
 			expectedError: false,
 		},
@@ -548,7 +794,7 @@ func TestDeleteCountry(t *testing.T) {
 			name:    "Data base error",
 			inputId: "TT",
 			mock: func(countryId string) {
-				mock.ExpectExec("DELETE FROM countries").WithArgs(countryId, countryId).WillReturnError(errors.New("data base error"))
+				mock.ExpectExec("DELETE FROM countries").WithArgs(countryId).WillReturnError(errors.New("data base error"))
 			},
 			expectedError: true,
 		},
@@ -556,7 +802,7 @@ func TestDeleteCountry(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputId)
-			err := r.DeleteCountry(tt.inputId)
+			err := r.DeleteCountry(context.Background(), tt.inputId)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -577,25 +823,36 @@ func TestCheckCountryId(t *testing.T) {
 	r := NewRepository(db, logger)
 
 	testTable := []struct {
-		name          string
-		mock          func(countryId string)
-		inputId       string
-		expectedError bool
+		name             string
+		mock             func(countryId string)
+		inputId          string
+		expectedError    bool
+		expectedNotExist bool
 	}{
 		{
 			name:    "OK",
 			inputId: "TT",
 			mock: func(countryId string) {
 				rows := sqlmock.NewRows([]string{"exist"}).AddRow(true)
-				mock.ExpectQuery("SELECT EXISTS ").WithArgs(countryId, countryId).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT EXISTS ").WithArgs(countryId).WillReturnRows(rows)
 			},
 			expectedError: false,
 		},
+		{
+			name:    "Country with such id does not exist",
+			inputId: "TT",
+			mock: func(countryId string) {
+				rows := sqlmock.NewRows([]string{"exist"}).AddRow(false)
+				mock.ExpectQuery("SELECT EXISTS ").WithArgs(countryId).WillReturnRows(rows)
+			},
+			expectedError:    true,
+			expectedNotExist: true,
+		},
 		{
 			name:    "Data base error",
 			inputId: "TT",
 			mock: func(countryId string) {
-				mock.ExpectQuery("SELECT EXISTS ").WithArgs(countryId, countryId).WillReturnError(errors.New("data base error"))
+				mock.ExpectQuery("SELECT EXISTS ").WithArgs(countryId).WillReturnError(errors.New("data base error"))
 			},
 			expectedError: true,
 		},
@@ -603,9 +860,10 @@ func TestCheckCountryId(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputId)
-			err := r.CheckCountryId(tt.inputId)
+			err := r.CheckCountryId(context.Background(), tt.inputId)
 			if tt.expectedError {
 				assert.Error(t, err)
+				assert.Equal(t, tt.expectedNotExist, errors.Is(err, MyErrors.DoesNotExist))
 			} else {
 				assert.NoError(t, err)
 			}
@@ -699,7 +957,7 @@ func TestLoadImages(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputCountries)
-			err := r.LoadImages(tt.inputCountries)
+			err := r.LoadImages(context.Background(), tt.inputCountries)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
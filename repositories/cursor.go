@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"strings"
+	"tranee_service/models"
+)
+
+// defaultCursorLimit is the page size of the cursor mode when the limit is not passed.
+const defaultCursorLimit = 10
+
+// cursor is the content of the opaque token that points at the last row of the previous page.
+// It keeps the sort the token was created for, so a token can not be reused with another order.
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+func sortSignature(sort []models.SortField) string {
+	var fields []string
+	for _, field := range sort {
+		if field.Desc {
+			fields = append(fields, "-"+field.Field)
+		} else {
+			fields = append(fields, field.Field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+func encodeCursor(sort []models.SortField, values []interface{}) (string, error) {
+	data, err := json.Marshal(cursor{Sort: sortSignature(sort), Values: values})
+	if err != nil {
+		return "", fmt.Errorf("encodeCursor: error while marshaling cursor:%w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the values of the sort fields stored in the token. An empty token means the first page
+// and returns nil values.
+func decodeCursor(token string, sort []models.SortField) ([]interface{}, error) {
+	var c cursor
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("decodeCursor: error while decoding cursor:%w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("decodeCursor: error while unmarshaling cursor:%w", err)
+	}
+	if c.Sort != sortSignature(sort) || len(c.Values) != len(sort) {
+		return nil, fmt.Errorf("decodeCursor: cursor was created for another sort")
+	}
+	for i, value := range c.Values {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		if n, err := number.Int64(); err == nil {
+			c.Values[i] = n
+		} else if f, err := number.Float64(); err == nil {
+			c.Values[i] = f
+		}
+	}
+	return c.Values, nil
+}
+
+// keysetCondition selects the rows that follow the row with the given values of the sort fields:
+// (a > ?) OR (a = ? AND b > ?) OR ...
+func keysetCondition(sort []models.SortField, columns map[string]string, values []interface{}) (squirrel.Or, error) {
+	var condition squirrel.Or
+	for i, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, fmt.Errorf("keysetCondition: field %q can not be used for sorting", field.Field)
+		}
+		var and squirrel.And
+		for j := 0; j < i; j++ {
+			and = append(and, squirrel.Eq{columns[sort[j].Field]: values[j]})
+		}
+		if field.Desc {
+			and = append(and, squirrel.Lt{column: values[i]})
+		} else {
+			and = append(and, squirrel.Gt{column: values[i]})
+		}
+		condition = append(condition, and)
+	}
+	return condition, nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"strings"
+	"tranee_service/MyErrors"
+)
+
+// The numbers of the MySQL errors about the values that violate the constraints of the schema.
+const (
+	errDuplicateEntry = 1062
+	errDataTooLong    = 1406
+	errOutOfRange     = 1264
+	errDataTruncated  = 1265
+)
+
+// keyFields maps the unique keys of the schema to the fields of the API they are set by. The keys of the columns
+// declared as UNIQUE have the names of the columns, the names of the other keys are given in the migrations.
+var keyFields = map[string]string{
+	"name":                     "name",
+	"alpha_2":                  "alpha_2",
+	"alpha_3":                  "alpha_3",
+	"iso":                      "iso",
+	"email":                    "email",
+	"users_hobbies_user_hobby": "hobbies",
+}
+
+// constraintError turns the MySQL errors about a violation of the UNIQUE constraint into a *MyErrors.ConflictError
+// and about a value that does not fit into the column into a *MyErrors.ValidationError. Other errors are returned as is.
+// The conflict of an unknown key does not name the field, so that the names of the schema are not sent to the client.
+func constraintError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	switch mysqlErr.Number {
+	case errDuplicateEntry:
+		return &MyErrors.ConflictError{Field: keyFields[quotedName(mysqlErr.Message, "for key '")]}
+	case errDataTooLong, errOutOfRange, errDataTruncated:
+		return &MyErrors.ValidationError{Truncated: []string{quotedName(mysqlErr.Message, "for column '")}}
+	}
+	return err
+}
+
+// quotedName returns the name that follows the prefix in the messages such as
+// "Duplicate entry 'x' for key 'users.email'" or "Data too long for column 'name' at row 1".
+// MySQL before 8.0.19 does not prefix the key with the table.
+func quotedName(message, prefix string) string {
+	i := strings.LastIndex(message, prefix)
+	if i < 0 {
+		return ""
+	}
+	name := message[i+len(prefix):]
+	if end := strings.Index(name, "'"); end >= 0 {
+		name = name[:end]
+	}
+	return name[strings.LastIndex(name, ".")+1:]
+}
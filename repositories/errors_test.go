@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"tranee_service/MyErrors"
+)
+
+func TestConstraintError(t *testing.T) {
+	testTable := []struct {
+		name          string
+		inputError    error
+		expectedError error
+	}{
+		{
+			name:          "Key of column",
+			inputError:    &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'TT' for key 'countries.alpha_2'"},
+			expectedError: &MyErrors.ConflictError{Field: "alpha_2"},
+		},
+		{
+			name:          "Key without table",
+			inputError:    &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'test@test.ru' for key 'email'"},
+			expectedError: &MyErrors.ConflictError{Field: "email"},
+		},
+		{
+			name:          "Named key",
+			inputError:    &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'users_hobbies.users_hobbies_user_hobby'"},
+			expectedError: &MyErrors.ConflictError{Field: "hobbies"},
+		},
+		{
+			name:          "Unknown key",
+			inputError:    &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'countries.PRIMARY'"},
+			expectedError: &MyErrors.ConflictError{},
+		},
+		{
+			name:          "Value too long",
+			inputError:    &mysql.MySQLError{Number: 1406, Message: "Data too long for column 'location' at row 1"},
+			expectedError: &MyErrors.ValidationError{Truncated: []string{"location"}},
+		},
+		{
+			name:          "Other error",
+			inputError:    errors.New("connection refused"),
+			expectedError: errors.New("connection refused"),
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedError, constraintError(tt.inputError))
+		})
+	}
+}
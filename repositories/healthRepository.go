@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tranee_service/internal/logging"
+)
+
+// SchemaVersion is the version of the last migration in the migrations directory. The service is not ready
+// until the data base has been migrated at least to this version.
+const SchemaVersion = 3
+
+type HealthRepository struct {
+	db     *sql.DB
+	logger logging.Logger
+}
+
+func NewHealthRepository(db *sql.DB, logger logging.Logger) *HealthRepository {
+	return &HealthRepository{db: db, logger: logger}
+}
+
+func (h *HealthRepository) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, h.logger)
+}
+
+func (h *HealthRepository) Ping(ctx context.Context) error {
+	if err := h.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping: data base is not reachable:%w", err)
+	}
+	return nil
+}
+
+// MigrationVersion returns the version of the schema applied by migrate and whether the last migration failed halfway.
+func (h *HealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version uint
+	var dirty bool
+	query := "SELECT version, dirty FROM schema_migrations LIMIT 1"
+	err := h.db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		h.log(ctx).Errorf("MigrationVersion: error while scanning for version:%s", err)
+		return 0, false, fmt.Errorf("migrationVersion: error while scanning for version:%w", err)
+	}
+	return version, dirty, nil
+}
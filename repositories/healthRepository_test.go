@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"tranee_service/internal/logging"
+)
+
+func TestMigrationVersion(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name            string
+		mock            func()
+		expectedVersion uint
+		expectedDirty   bool
+		expectedError   bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, false)
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+			},
+			expectedVersion: 3,
+			expectedDirty:   false,
+			expectedError:   false,
+		},
+		{
+			name: "Dirty",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, true)
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+			},
+			expectedVersion: 2,
+			expectedDirty:   true,
+			expectedError:   false,
+		},
+		{
+			name: "No migrations",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"version", "dirty"})
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+			},
+			expectedVersion: 0,
+			expectedDirty:   false,
+			expectedError:   false,
+		},
+		{
+			name: "Data base error",
+			mock: func() {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnError(errors.New("data base error"))
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			version, dirty, err := r.MigrationVersion(context.Background())
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedVersion, version)
+				assert.Equal(t, tt.expectedDirty, dirty)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/pkg/errors"
@@ -18,63 +19,253 @@ func NewHobbyRepository(db *sql.DB, logger logging.Logger) *HobbyRepository {
 	return &HobbyRepository{db: db, logger: logger}
 }
 
-func (h *HobbyRepository) GetHobbyByUserId(userId int) ([]int, error) {
+func (h *HobbyRepository) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, h.logger)
+}
+
+func (h *HobbyRepository) GetHobbyByUserId(ctx context.Context, userId int) ([]int, error) {
 	var ids []int
 	query := "SELECT hobby_id from users_hobbies WHERE user_id = ?"
-	rows, err := h.db.Query(query, userId)
+	rows, err := h.db.QueryContext(ctx, query, userId)
 	if err != nil {
-		h.logger.Errorf("GetHobbyByUserId: can not executes a query:%s", err)
-		return nil, fmt.Errorf("getHobbyByUserId: can not executes a query:%s", err)
+		h.log(ctx).Errorf("GetHobbyByUserId: can not executes a query:%s", err)
+		return nil, fmt.Errorf("getHobbyByUserId: can not executes a query:%w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var id int
 		if err := rows.Scan(&id); err != nil {
-			h.logger.Errorf("Error while scanning for hobby id:%s", err)
+			h.log(ctx).Errorf("Error while scanning for hobby id:%s", err)
 			return nil, fmt.Errorf("getHobbyByUserId:repository error:%w", err)
 		}
 		ids = append(ids, id)
 	}
 	if len(ids) == 0 {
-		h.logger.Errorf("GetHobbyByUserId:object with this id does not exist")
+		h.log(ctx).Errorf("GetHobbyByUserId:object with this id does not exist")
 		return nil, errors.Wrap(MyErrors.DoesNotExist, "getHobbyByUserId")
 	}
 	return ids, nil
 }
 
-func (h *HobbyRepository) CreateHobby(hobby *models.Hobby) (int, error) {
+func (h *HobbyRepository) CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error) {
 	var id int
 	query := "INSERT INTO hobbies (name) VALUES (?)"
-	result, err := h.db.Exec(query, hobby.Name)
+	result, err := h.db.ExecContext(ctx, query, hobby.Name)
 	if err != nil {
-		h.logger.Errorf("CreateHobby: can not adding new hobby:%s", err)
-		return 0, fmt.Errorf("createHobby: can not adding new hobby:%w", err)
+		h.log(ctx).Errorf("CreateHobby: can not adding new hobby:%s", err)
+		return 0, fmt.Errorf("createHobby: can not adding new hobby:%w", constraintError(err))
 	}
 	insertId, err := result.LastInsertId()
 	if err != nil {
-		h.logger.Errorf("CreateHobby: error while getting insertId:%s", err)
+		h.log(ctx).Errorf("CreateHobby: error while getting insertId:%s", err)
 		return 0, fmt.Errorf("createHobby: error while getting insertId:%w", err)
 	}
 	id = int(insertId)
 	return id, nil
 }
 
-func (h *HobbyRepository) GetHobbies() ([]models.ResponseHobby, error) {
+func (h *HobbyRepository) GetHobbies(ctx context.Context) ([]models.ResponseHobby, error) {
 	var hobbies []models.ResponseHobby
 	query := "SELECT id, name FROM hobbies"
-	rows, err := h.db.Query(query)
+	rows, err := h.db.QueryContext(ctx, query)
 	if err != nil {
-		h.logger.Errorf("GetHobbies: can not executes a query:%s", err)
-		return nil, fmt.Errorf("getHobbies: can not executes a query:%s", err)
+		h.log(ctx).Errorf("GetHobbies: can not executes a query:%s", err)
+		return nil, fmt.Errorf("getHobbies: can not executes a query:%w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var hobby models.ResponseHobby
 		if err := rows.Scan(&hobby.Id, &hobby.Name); err != nil {
-			h.logger.Errorf("Error while scanning for hobby:%s", err)
+			h.log(ctx).Errorf("Error while scanning for hobby:%s", err)
 			return nil, fmt.Errorf("getHobbies:repository error:%w", err)
 		}
 		hobbies = append(hobbies, hobby)
 	}
 	return hobbies, nil
 }
+
+func (h *HobbyRepository) GetHobbyById(ctx context.Context, hobbyId int) (*models.ResponseHobby, error) {
+	var hobby models.ResponseHobby
+	query := "SELECT id, name FROM hobbies WHERE id = ?"
+	row := h.db.QueryRowContext(ctx, query, hobbyId)
+	if err := row.Scan(&hobby.Id, &hobby.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.log(ctx).Errorf("GetHobbyById:object with this id does not exist")
+			return nil, errors.Wrap(MyErrors.DoesNotExist, "getHobbyById")
+		}
+		h.log(ctx).Errorf("Error while scanning for hobby:%s", err)
+		return nil, fmt.Errorf("getHobbyById: repository error:%w", err)
+	}
+	return &hobby, nil
+}
+
+func (h *HobbyRepository) ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error {
+	var id int
+	transaction, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Errorf("ChangeHobby: can not starts transaction:%s", err)
+		return fmt.Errorf("changeHobby: can not starts transaction:%w", err)
+	}
+	defer transaction.Rollback()
+	query := "SELECT id FROM hobbies WHERE id = ? FOR UPDATE"
+	row := transaction.QueryRowContext(ctx, query, hobbyId)
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.log(ctx).Errorf("ChangeHobby:object with this id does not exist")
+			return errors.Wrap(MyErrors.DoesNotExist, "changeHobby")
+		}
+		h.log(ctx).Errorf("ChangeHobby: error while scanning for hobby:%s", err)
+		return fmt.Errorf("changeHobby: error while scanning for hobby:%w", err)
+	}
+	query = "UPDATE hobbies SET name = ? WHERE id = ?"
+	if _, err = transaction.ExecContext(ctx, query, hobby.Name, hobbyId); err != nil {
+		h.log(ctx).Errorf("ChangeHobby: error while updating hobby:%s", err)
+		return fmt.Errorf("changeHobby: error while updating hobby:%w", constraintError(err))
+	}
+	return transaction.Commit()
+}
+
+func (h *HobbyRepository) DeleteHobby(ctx context.Context, hobbyId int) error {
+	query := "DELETE FROM hobbies WHERE id = ?"
+	result, err := h.db.ExecContext(ctx, query, hobbyId)
+	if err != nil {
+		h.log(ctx).Errorf("DeleteHobby: can not executes a query:%s", err)
+		return fmt.Errorf("deleteHobby: can not executes a query:%w", err)
+	}
+	numberRows, err := result.RowsAffected()
+	if err != nil {
+		h.log(ctx).Errorf("Error while getting number affected rows:%s", err)
+		return fmt.Errorf("deleteHobby: error while getting number affected rows:%w", err)
+	}
+	if numberRows == 0 {
+		h.log(ctx).Errorf("DeleteHobby:object with this id does not exist")
+		return errors.Wrap(MyErrors.DoesNotExist, "deleteHobby")
+	}
+	return nil
+}
+
+// GetUsersByHobbyId returns the users that have the hobby, each with the full list of their hobbies.
+func (h *HobbyRepository) GetUsersByHobbyId(ctx context.Context, hobbyId int) ([]models.ResponseUser, error) {
+	var exist bool
+	var users []models.ResponseUser
+	query := "SELECT EXISTS (SELECT 1 FROM hobbies WHERE id = ?)"
+	row := h.db.QueryRowContext(ctx, query, hobbyId)
+	if err := row.Scan(&exist); err != nil {
+		h.log(ctx).Errorf("Error while scanning for existing hobby:%s", err)
+		return nil, fmt.Errorf("getUsersByHobbyId: error while scanning for existing hobby:%w", err)
+	}
+	if !exist {
+		h.log(ctx).Errorf("GetUsersByHobbyId:object with this id does not exist")
+		return nil, errors.Wrap(MyErrors.DoesNotExist, "getUsersByHobbyId")
+	}
+	// The hobby is looked for in a subquery, so that the list of the hobbies of every user stays full.
+	query, args, err := userSelect(models.Expand{}).
+		Where("users.id IN (SELECT user_id FROM users_hobbies WHERE hobby_id = ?)", hobbyId).
+		OrderBy("users.id").ToSql()
+	if err != nil {
+		h.log(ctx).Errorf("GetUsersByHobbyId: can not builds the query into a SQL:%s", err)
+		return nil, fmt.Errorf("getUsersByHobbyId: can not builds the query into a SQL:%w", err)
+	}
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		h.log(ctx).Errorf("GetUsersByHobbyId: can not executes a query:%s", err)
+		return nil, fmt.Errorf("getUsersByHobbyId: can not executes a query:%w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanUser(rows, models.Expand{})
+		if err != nil {
+			h.log(ctx).Errorf("Error while scanning for user:%s", err)
+			return nil, fmt.Errorf("getUsersByHobbyId:repository error:%w", err)
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+// AddUserHobby adds the hobby to the user. Adding a hobby the user already has does nothing.
+func (h *HobbyRepository) AddUserHobby(ctx context.Context, userId, hobbyId int) error {
+	var exist bool
+	transaction, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Errorf("AddUserHobby: can not starts transaction:%s", err)
+		return fmt.Errorf("addUserHobby: can not starts transaction:%w", err)
+	}
+	defer transaction.Rollback()
+	if err := lockUser(ctx, transaction, userId); err != nil {
+		h.log(ctx).Errorf("AddUserHobby: %s", err)
+		return errors.Wrap(err, "addUserHobby")
+	}
+	query := "SELECT EXISTS (SELECT 1 FROM hobbies WHERE id = ?)"
+	row := transaction.QueryRowContext(ctx, query, hobbyId)
+	if err := row.Scan(&exist); err != nil {
+		h.log(ctx).Errorf("Error while scanning for existing hobby:%s", err)
+		return fmt.Errorf("addUserHobby: error while scanning for existing hobby:%w", err)
+	}
+	if !exist {
+		h.log(ctx).Errorf("AddUserHobby:object with this id does not exist")
+		return errors.Wrap(MyErrors.DoesNotExist, "addUserHobby")
+	}
+	query = "INSERT INTO users_hobbies (user_id, hobby_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE hobby_id = hobby_id"
+	if _, err = transaction.ExecContext(ctx, query, userId, hobbyId); err != nil {
+		h.log(ctx).Errorf("AddUserHobby: error while insert users_hobbies:%s", err)
+		return fmt.Errorf("addUserHobby: error while insert users_hobbies:%w", err)
+	}
+	return transaction.Commit()
+}
+
+// DeleteUserHobby removes the hobby from the user. The last hobby of the user can not be removed.
+func (h *HobbyRepository) DeleteUserHobby(ctx context.Context, userId, hobbyId int) error {
+	var numberHobbies int
+	transaction, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Errorf("DeleteUserHobby: can not starts transaction:%s", err)
+		return fmt.Errorf("deleteUserHobby: can not starts transaction:%w", err)
+	}
+	defer transaction.Rollback()
+	if err := lockUser(ctx, transaction, userId); err != nil {
+		h.log(ctx).Errorf("DeleteUserHobby: %s", err)
+		return errors.Wrap(err, "deleteUserHobby")
+	}
+	query := "SELECT COUNT(*) FROM users_hobbies WHERE user_id = ?"
+	row := transaction.QueryRowContext(ctx, query, userId)
+	if err := row.Scan(&numberHobbies); err != nil {
+		h.log(ctx).Errorf("Error while scanning for number of hobbies:%s", err)
+		return fmt.Errorf("deleteUserHobby: error while scanning for number of hobbies:%w", err)
+	}
+	query = "DELETE FROM users_hobbies WHERE user_id = ? AND hobby_id = ?"
+	result, err := transaction.ExecContext(ctx, query, userId, hobbyId)
+	if err != nil {
+		h.log(ctx).Errorf("DeleteUserHobby: can not executes a query:%s", err)
+		return fmt.Errorf("deleteUserHobby: can not executes a query:%w", err)
+	}
+	numberRows, err := result.RowsAffected()
+	if err != nil {
+		h.log(ctx).Errorf("Error while getting number affected rows:%s", err)
+		return fmt.Errorf("deleteUserHobby: error while getting number affected rows:%w", err)
+	}
+	if numberRows == 0 {
+		h.log(ctx).Errorf("DeleteUserHobby:object with this id does not exist")
+		return errors.Wrap(MyErrors.DoesNotExist, "deleteUserHobby")
+	}
+	if numberHobbies <= 1 {
+		h.log(ctx).Errorf("DeleteUserHobby: %s", MyErrors.LastHobby)
+		return errors.Wrap(MyErrors.LastHobby, "deleteUserHobby")
+	}
+	return transaction.Commit()
+}
+
+// lockUser locks the row of the user until the end of the transaction, so that changes of the user`s hobbies
+// do not interleave with each other.
+func lockUser(ctx context.Context, tr *sql.Tx, userId int) error {
+	var id int
+	query := "SELECT id FROM users WHERE id = ? FOR UPDATE"
+	row := tr.QueryRowContext(ctx, query, userId)
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return MyErrors.DoesNotExist
+		}
+		return fmt.Errorf("error while scanning for user:%w", err)
+	}
+	return nil
+}
@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 )
@@ -54,7 +57,7 @@ func TestGetHobbies(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			hobbies, err := r.GetHobbies()
+			hobbies, err := r.GetHobbies(context.Background())
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -105,7 +108,7 @@ func TestGetHobbyByUserId(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputId)
-			hobbies, err := r.GetHobbyByUserId(tt.inputId)
+			hobbies, err := r.GetHobbyByUserId(context.Background(), tt.inputId)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -127,11 +130,12 @@ func TestCreateHobby(t *testing.T) {
 	r := NewRepository(db, logger)
 
 	testTable := []struct {
-		name           string
-		mock           func(hobby *models.Hobby)
-		inputHobby     *models.Hobby
-		expectedResult int
-		expectedError  bool
+		name             string
+		mock             func(hobby *models.Hobby)
+		inputHobby       *models.Hobby
+		expectedResult   int
+		expectedError    bool
+		expectedConflict string
 	}{
 		{
 			name:       "OK",
@@ -151,13 +155,30 @@ func TestCreateHobby(t *testing.T) {
 			},
 			expectedError: true,
 		},
+		{
+			name:       "Hobby with such name already exists",
+			inputHobby: &models.Hobby{Name: "testName"},
+			mock: func(hobby *models.Hobby) {
+				mock.ExpectExec("INSERT INTO hobbies ").WillReturnError(&mysql.MySQLError{
+					Number:  1062,
+					Message: "Duplicate entry 'testName' for key 'hobbies.name'",
+				})
+			},
+			expectedError:    true,
+			expectedConflict: "name",
+		},
 	}
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputHobby)
-			hobbies, err := r.CreateHobby(tt.inputHobby)
+			hobbies, err := r.CreateHobby(context.Background(), tt.inputHobby)
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedConflict != "" {
+					var conflict *MyErrors.ConflictError
+					assert.ErrorAs(t, err, &conflict)
+					assert.Equal(t, tt.expectedConflict, conflict.Field)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedResult, hobbies)
@@ -166,3 +187,429 @@ func TestCreateHobby(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHobbyById(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name           string
+		mock           func(hobbyId int)
+		inputId        int
+		expectedResult *models.ResponseHobby
+		expectedError  bool
+	}{
+		{
+			name:    "OK",
+			inputId: 1,
+			mock: func(hobbyId int) {
+				rows := sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test name")
+				mock.ExpectQuery("SELECT id, name FROM hobbies").WithArgs(hobbyId).WillReturnRows(rows)
+			},
+			expectedResult: &models.ResponseHobby{Id: 1, Name: "test name"},
+			expectedError:  false,
+		},
+		{
+			name:    "Hobby with such id does not exist",
+			inputId: 1,
+			mock: func(hobbyId int) {
+				rows := sqlmock.NewRows([]string{"id", "name"})
+				mock.ExpectQuery("SELECT id, name FROM hobbies").WithArgs(hobbyId).WillReturnRows(rows)
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.inputId)
+			hobby, err := r.GetHobbyById(context.Background(), tt.inputId)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, hobby)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestChangeHobby(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name          string
+		mock          func(hobby *models.Hobby, hobbyId int)
+		inputHobby    *models.Hobby
+		inputId       int
+		expectedError bool
+	}{
+		{
+			name:       "OK",
+			inputHobby: &models.Hobby{Name: "testName"},
+			inputId:    1,
+			mock: func(hobby *models.Hobby, hobbyId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(hobbyId)
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(hobbyId).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE hobbies SET name").WithArgs(hobby.Name, hobbyId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Hobby with such id does not exist",
+			inputHobby: &models.Hobby{Name: "testName"},
+			inputId:    1,
+			mock: func(hobby *models.Hobby, hobbyId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"})
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(hobbyId).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+		{
+			name:       "Data base error",
+			inputHobby: &models.Hobby{Name: "testName"},
+			inputId:    1,
+			mock: func(hobby *models.Hobby, hobbyId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(hobbyId)
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(hobbyId).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE hobbies SET name").WillReturnError(errors.New("data base error"))
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.inputHobby, tt.inputId)
+			err := r.ChangeHobby(context.Background(), tt.inputHobby, tt.inputId)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteHobby(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name          string
+		mock          func(hobbyId int)
+		inputId       int
+		expectedError bool
+	}{
+		{
+			name:    "OK",
+			inputId: 1,
+			mock: func(hobbyId int) {
+				mock.ExpectExec("DELETE FROM hobbies").WithArgs(hobbyId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Hobby with such id does not exist",
+			inputId: 1,
+			mock: func(hobbyId int) {
+				mock.ExpectExec("DELETE FROM hobbies").WithArgs(hobbyId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: true,
+		},
+		{
+			name:    "Data base error",
+			inputId: 1,
+			mock: func(hobbyId int) {
+				mock.ExpectExec("DELETE FROM hobbies").WithArgs(hobbyId).WillReturnError(errors.New("data base error"))
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.inputId)
+			err := r.DeleteHobby(context.Background(), tt.inputId)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUsersByHobbyId(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name           string
+		mock           func(hobbyId int)
+		inputId        int
+		expectedResult []models.ResponseUser
+		expectedError  bool
+	}{
+		{
+			name:    "OK",
+			inputId: 2,
+			mock: func(hobbyId int) {
+				rows := sqlmock.NewRows([]string{"exist"}).AddRow(true)
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(hobbyId).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"id", "name", "email", "description", "countryId", "list"}).
+					AddRow(1, "test name", "test email", "test desc", 1, []byte("1"+","+"2"))
+				mock.ExpectQuery(`SELECT users.id, .+ FROM users JOIN users_hobbies .+ WHERE users.id IN \(SELECT user_id FROM users_hobbies WHERE hobby_id = \?\) GROUP BY users.id ORDER BY users.id`).WithArgs(hobbyId).WillReturnRows(rows)
+			},
+			expectedResult: []models.ResponseUser{
+				{
+					Id:          1,
+					Name:        "test name",
+					Email:       "test email",
+					Description: "test desc",
+					CountryId:   1,
+					Hobbies:     []int{1, 2},
+				},
+			},
+			expectedError: false,
+		},
+		{
+			name:    "Hobby with such id does not exist",
+			inputId: 2,
+			mock: func(hobbyId int) {
+				rows := sqlmock.NewRows([]string{"exist"}).AddRow(false)
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(hobbyId).WillReturnRows(rows)
+			},
+			expectedError: true,
+		},
+		{
+			name:    "Data base error",
+			inputId: 2,
+			mock: func(hobbyId int) {
+				rows := sqlmock.NewRows([]string{"exist"}).AddRow(true)
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(hobbyId).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT users.id, ").WillReturnError(errors.New("data base error"))
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.inputId)
+			users, err := r.GetUsersByHobbyId(context.Background(), tt.inputId)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, users)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddUserHobby(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name          string
+		mock          func(userId, hobbyId int)
+		userId        int
+		hobbyId       int
+		expectedError bool
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userId))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(hobbyId).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("INSERT INTO users_hobbies (.+) ON DUPLICATE KEY UPDATE").WithArgs(userId, hobbyId).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedError: false,
+		},
+		{
+			name:    "User does not exist",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+		{
+			name:    "Hobby does not exist",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userId))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(hobbyId).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+		{
+			name:    "Data base error",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userId))
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(hobbyId).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("INSERT INTO users_hobbies").WithArgs(userId, hobbyId).
+					WillReturnError(errors.New("data base error"))
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.userId, tt.hobbyId)
+			err := r.AddUserHobby(context.Background(), tt.userId, tt.hobbyId)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteUserHobby(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+
+	testTable := []struct {
+		name          string
+		mock          func(userId, hobbyId int)
+		userId        int
+		hobbyId       int
+		expectedError error
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userId))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec("DELETE FROM users_hobbies").WithArgs(userId, hobbyId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "User does not have this hobby",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userId))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec("DELETE FROM users_hobbies").WithArgs(userId, hobbyId).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedError: MyErrors.DoesNotExist,
+		},
+		{
+			name:    "Last hobby of the user",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userId))
+				mock.ExpectQuery("SELECT COUNT").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectExec("DELETE FROM users_hobbies").WithArgs(userId, hobbyId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectRollback()
+			},
+			expectedError: MyErrors.LastHobby,
+		},
+		{
+			name:    "User does not exist",
+			userId:  1,
+			hobbyId: 2,
+			mock: func(userId, hobbyId int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id FROM users WHERE (.+) FOR UPDATE").WithArgs(userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			expectedError: MyErrors.DoesNotExist,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.userId, tt.hobbyId)
+			err := r.DeleteUserHobby(context.Background(), tt.userId, tt.hobbyId)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
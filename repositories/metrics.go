@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tranee_service/models"
+)
+
+// QueryObserver records the duration of the repository methods.
+type QueryObserver interface {
+	ObserveQuery(repository, method string, duration time.Duration)
+}
+
+// Instrument wraps the repositories so that the duration of every method is passed to the observer.
+func Instrument(repository *Repository, observer QueryObserver) *Repository {
+	return &Repository{
+		AppCountry: &countryMetrics{next: repository.AppCountry, observer: observer},
+		AppUsers:   &userMetrics{next: repository.AppUsers, observer: observer},
+		AppHobbies: &hobbyMetrics{next: repository.AppHobbies, observer: observer},
+		AppHealth:  repository.AppHealth,
+	}
+}
+
+func observe(observer QueryObserver, repository, method string) func() {
+	start := time.Now()
+	return func() {
+		observer.ObserveQuery(repository, method, time.Since(start))
+	}
+}
+
+type countryMetrics struct {
+	next     AppCountry
+	observer QueryObserver
+}
+
+func (c *countryMetrics) SaveInitialCountries(ctx context.Context, countries []models.Country) error {
+	defer observe(c.observer, "country", "SaveInitialCountries")()
+	return c.next.SaveInitialCountries(ctx, countries)
+}
+
+func (c *countryMetrics) GetOneCountry(ctx context.Context, id string) (*models.Country, error) {
+	defer observe(c.observer, "country", "GetOneCountry")()
+	return c.next.GetOneCountry(ctx, id)
+}
+
+func (c *countryMetrics) GetCountries(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error) {
+	defer observe(c.observer, "country", "GetCountries")()
+	return c.next.GetCountries(ctx, filters)
+}
+
+func (c *countryMetrics) CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error) {
+	defer observe(c.observer, "country", "CreateCountry")()
+	return c.next.CreateCountry(ctx, country)
+}
+
+func (c *countryMetrics) ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error) {
+	defer observe(c.observer, "country", "ChangeCountry")()
+	return c.next.ChangeCountry(ctx, country, countryId)
+}
+
+func (c *countryMetrics) PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error) {
+	defer observe(c.observer, "country", "PatchCountry")()
+	return c.next.PatchCountry(ctx, patch, countryId)
+}
+
+func (c *countryMetrics) DeleteCountry(ctx context.Context, countryId string) error {
+	defer observe(c.observer, "country", "DeleteCountry")()
+	return c.next.DeleteCountry(ctx, countryId)
+}
+
+func (c *countryMetrics) CheckCountryId(ctx context.Context, countryId string) error {
+	defer observe(c.observer, "country", "CheckCountryId")()
+	return c.next.CheckCountryId(ctx, countryId)
+}
+
+func (c *countryMetrics) LoadImages(ctx context.Context, countries []models.Country) error {
+	defer observe(c.observer, "country", "LoadImages")()
+	return c.next.LoadImages(ctx, countries)
+}
+
+type userMetrics struct {
+	next     AppUsers
+	observer QueryObserver
+}
+
+func (u *userMetrics) CreateUser(ctx context.Context, user *models.User) (int, error) {
+	defer observe(u.observer, "user", "CreateUser")()
+	return u.next.CreateUser(ctx, user)
+}
+
+func (u *userMetrics) GetUserById(ctx context.Context, userId int, expand models.Expand) (*models.ResponseUser, error) {
+	defer observe(u.observer, "user", "GetUserById")()
+	return u.next.GetUserById(ctx, userId, expand)
+}
+
+func (u *userMetrics) GetUsers(ctx context.Context, options *models.Options) ([]models.ResponseUser, models.PageInfo, error) {
+	defer observe(u.observer, "user", "GetUsers")()
+	return u.next.GetUsers(ctx, options)
+}
+
+func (u *userMetrics) ChangeUser(ctx context.Context, user *models.User, userId int) error {
+	defer observe(u.observer, "user", "ChangeUser")()
+	return u.next.ChangeUser(ctx, user, userId)
+}
+
+func (u *userMetrics) PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error {
+	defer observe(u.observer, "user", "PatchUser")()
+	return u.next.PatchUser(ctx, patch, userId)
+}
+
+func (u *userMetrics) DeleteUser(ctx context.Context, userId int) error {
+	defer observe(u.observer, "user", "DeleteUser")()
+	return u.next.DeleteUser(ctx, userId)
+}
+
+type hobbyMetrics struct {
+	next     AppHobbies
+	observer QueryObserver
+}
+
+func (h *hobbyMetrics) CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error) {
+	defer observe(h.observer, "hobby", "CreateHobby")()
+	return h.next.CreateHobby(ctx, hobby)
+}
+
+func (h *hobbyMetrics) GetHobbyByUserId(ctx context.Context, userId int) ([]int, error) {
+	defer observe(h.observer, "hobby", "GetHobbyByUserId")()
+	return h.next.GetHobbyByUserId(ctx, userId)
+}
+
+func (h *hobbyMetrics) GetHobbies(ctx context.Context) ([]models.ResponseHobby, error) {
+	defer observe(h.observer, "hobby", "GetHobbies")()
+	return h.next.GetHobbies(ctx)
+}
+
+func (h *hobbyMetrics) GetHobbyById(ctx context.Context, hobbyId int) (*models.ResponseHobby, error) {
+	defer observe(h.observer, "hobby", "GetHobbyById")()
+	return h.next.GetHobbyById(ctx, hobbyId)
+}
+
+func (h *hobbyMetrics) ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error {
+	defer observe(h.observer, "hobby", "ChangeHobby")()
+	return h.next.ChangeHobby(ctx, hobby, hobbyId)
+}
+
+func (h *hobbyMetrics) DeleteHobby(ctx context.Context, hobbyId int) error {
+	defer observe(h.observer, "hobby", "DeleteHobby")()
+	return h.next.DeleteHobby(ctx, hobbyId)
+}
+
+func (h *hobbyMetrics) GetUsersByHobbyId(ctx context.Context, hobbyId int) ([]models.ResponseUser, error) {
+	defer observe(h.observer, "hobby", "GetUsersByHobbyId")()
+	return h.next.GetUsersByHobbyId(ctx, hobbyId)
+}
+
+func (h *hobbyMetrics) AddUserHobby(ctx context.Context, userId, hobbyId int) error {
+	defer observe(h.observer, "hobby", "AddUserHobby")()
+	return h.next.AddUserHobby(ctx, userId, hobbyId)
+}
+
+func (h *hobbyMetrics) DeleteUserHobby(ctx context.Context, userId, hobbyId int) error {
+	defer observe(h.observer, "hobby", "DeleteUserHobby")()
+	return h.next.DeleteUserHobby(ctx, userId, hobbyId)
+}
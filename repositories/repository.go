@@ -1,40 +1,56 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 )
 
 type AppCountry interface {
-	SaveInitialCountries([]models.Country) error
-	GetOneCountry(id string) (*models.Country, error)
-	GetCountries(filters *models.Filters) ([]models.Country, int, error)
-	CreateCountry(country *models.ResponseCountry) (string, error)
-	ChangeCountry(country *models.ResponseCountry, countryId string) error
-	DeleteCountry(countryId string) error
-	CheckCountryId(countryId string) error
-	LoadImages(countries []models.Country) error
+	SaveInitialCountries(ctx context.Context, countries []models.Country) error
+	GetOneCountry(ctx context.Context, id string) (*models.Country, error)
+	GetCountries(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error)
+	CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error)
+	ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error)
+	PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error)
+	DeleteCountry(ctx context.Context, countryId string) error
+	CheckCountryId(ctx context.Context, countryId string) error
+	LoadImages(ctx context.Context, countries []models.Country) error
 }
 
 type AppUsers interface {
-	CreateUser(user *models.User) (int, error)
-	GetUserById(userId int) (*models.ResponseUser, error)
-	GetUsers(options *models.Options) ([]models.ResponseUser, int, error)
-	ChangeUser(user *models.User, userId int) error
-	DeleteUser(userId int) error
+	CreateUser(ctx context.Context, user *models.User) (int, error)
+	GetUserById(ctx context.Context, userId int, expand models.Expand) (*models.ResponseUser, error)
+	GetUsers(ctx context.Context, options *models.Options) ([]models.ResponseUser, models.PageInfo, error)
+	ChangeUser(ctx context.Context, user *models.User, userId int) error
+	PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error
+	DeleteUser(ctx context.Context, userId int) error
 }
 
 type AppHobbies interface {
-	CreateHobby(hobby *models.Hobby) (int, error)
-	GetHobbyByUserId(userId int) ([]int, error)
-	GetHobbies() ([]models.ResponseHobby, error)
+	CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error)
+	GetHobbyByUserId(ctx context.Context, userId int) ([]int, error)
+	GetHobbies(ctx context.Context) ([]models.ResponseHobby, error)
+	GetHobbyById(ctx context.Context, hobbyId int) (*models.ResponseHobby, error)
+	ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error
+	DeleteHobby(ctx context.Context, hobbyId int) error
+	GetUsersByHobbyId(ctx context.Context, hobbyId int) ([]models.ResponseUser, error)
+	AddUserHobby(ctx context.Context, userId, hobbyId int) error
+	DeleteUserHobby(ctx context.Context, userId, hobbyId int) error
+}
+
+type AppHealth interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (uint, bool, error)
 }
 
 type Repository struct {
 	AppCountry
 	AppUsers
 	AppHobbies
+	AppHealth
 }
 
 func NewRepository(db *sql.DB, logger logging.Logger) *Repository {
@@ -42,5 +58,35 @@ func NewRepository(db *sql.DB, logger logging.Logger) *Repository {
 		AppCountry: NewCountryRepository(db, logger),
 		AppUsers:   NewUserRepository(db, logger),
 		AppHobbies: NewHobbyRepository(db, logger),
+		AppHealth:  NewHealthRepository(db, logger),
+	}
+}
+
+// withTiebreaker appends the tiebreaker field to the requested sort fields, so that the order of rows stays
+// stable when the requested fields have equal values.
+func withTiebreaker(sort []models.SortField, tiebreaker string) []models.SortField {
+	for _, field := range sort {
+		if field.Field == tiebreaker {
+			return sort
+		}
+	}
+	result := make([]models.SortField, 0, len(sort)+1)
+	result = append(result, sort...)
+	return append(result, models.SortField{Field: tiebreaker})
+}
+
+// orderBy translates the sort fields into ORDER BY expressions.
+func orderBy(sort []models.SortField, columns map[string]string) ([]string, error) {
+	var order []string
+	for _, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, fmt.Errorf("orderBy: field %q can not be used for sorting", field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		order = append(order, column)
 	}
+	return order, nil
 }
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
@@ -21,33 +23,33 @@ func NewUserRepository(db *sql.DB, logger logging.Logger) *UserRepository {
 	return &UserRepository{db: db, logger: logger}
 }
 
-func (u *UserRepository) CreateUser(user *models.User) (int, error) {
+func (u *UserRepository) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, u.logger)
+}
+
+func (u *UserRepository) CreateUser(ctx context.Context, user *models.User) (int, error) {
 	var userId int
-	transaction, err := u.db.Begin()
+	transaction, err := u.db.BeginTx(ctx, nil)
 	if err != nil {
-		u.logger.Errorf("CreateUser: can not starts transaction:%s", err)
+		u.log(ctx).Errorf("CreateUser: can not starts transaction:%s", err)
 		return 0, fmt.Errorf("createUser: can not starts transaction:%w", err)
 	}
 	defer transaction.Rollback()
-	hobbiesId, err := CheckUserData(transaction, user)
+	hobbiesId, err := CheckUserData(ctx, transaction, user)
 	if err != nil {
-		u.logger.Errorf("CreateUser: error while checking user data:%s", err)
+		u.log(ctx).Errorf("CreateUser: error while checking user data:%s", err)
 		return 0, fmt.Errorf("createUser: error while checking user data:%w", err)
 	}
-	if len(hobbiesId) == 0 {
-		u.logger.Errorf("CreateUser: there are no correct hobbies for the user")
-		return 0, fmt.Errorf("createUser: there are no correct hobbies for the user")
-	}
 	user.Hobbies = hobbiesId
 	query := "INSERT INTO users (name, email, description, country_id) values (?, ?, ?, ?)"
-	result, err := transaction.Exec(query, user.Name, user.Email, user.Description, user.CountryId)
+	result, err := transaction.ExecContext(ctx, query, user.Name, user.Email, user.Description, user.CountryId)
 	if err != nil {
-		u.logger.Errorf("CreateUser: error while insert user:%s", err)
-		return 0, fmt.Errorf("createUser: error while insert user:%w", err)
+		u.log(ctx).Errorf("CreateUser: error while insert user:%s", err)
+		return 0, fmt.Errorf("createUser: error while insert user:%w", constraintError(err))
 	}
 	id, err := result.LastInsertId()
 	if err != nil {
-		u.logger.Errorf("CreateUser: error while getting insertId:%s", err)
+		u.log(ctx).Errorf("CreateUser: error while getting insertId:%s", err)
 		return 0, fmt.Errorf("createUser: error while getting insertId:%w", err)
 	}
 	userId = int(id)
@@ -59,140 +61,243 @@ func (u *UserRepository) CreateUser(user *models.User) (int, error) {
 		query += `(?,?),`
 	}
 	query = query[:len(query)-1]
-	_, err = transaction.Exec(query, values...)
+	_, err = transaction.ExecContext(ctx, query, values...)
 	if err != nil {
-		u.logger.Errorf("CreateUser: error while insert users_hobbies:%s", err)
+		u.log(ctx).Errorf("CreateUser: error while insert users_hobbies:%s", err)
 		return 0, fmt.Errorf("createUser: error while insert users_hobbies:%w", err)
 	}
 	return userId, transaction.Commit()
 }
 
-func (u *UserRepository) GetUserById(userId int) (*models.ResponseUser, error) {
-	var user models.ResponseUser
-	s := squirrel.Select("users.id, users.name, users.email, users.description, users.country_id, GROUP_CONCAT(users_hobbies.hobby_id) AS list").From("users").
-		Join("users_hobbies on users.id = users_hobbies.user_id").GroupBy("users.id").Where("users.id = ?", userId)
-	query, args, err := s.ToSql()
+func (u *UserRepository) GetUserById(ctx context.Context, userId int, expand models.Expand) (*models.ResponseUser, error) {
+	query, args, err := userSelect(expand).Where("users.id = ?", userId).ToSql()
 	if err != nil {
-		u.logger.Errorf("GetUserById: can not builds the query into a SQL:%s", err)
-		return nil, fmt.Errorf("getUserById: can not builds the query into a SQL:%s", err)
+		u.log(ctx).Errorf("GetUserById: can not builds the query into a SQL:%s", err)
+		return nil, fmt.Errorf("getUserById: can not builds the query into a SQL:%w", err)
 	}
-	row := u.db.QueryRow(query, args...)
-	var bytesHobby []byte
-	if err := row.Scan(&user.Id, &user.Name, &user.Email, &user.Description, &user.CountryId, &bytesHobby); err != nil {
+	row := u.db.QueryRowContext(ctx, query, args...)
+	user, err := scanUser(row, expand)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			u.logger.Errorf("GetUserById:object with this id does not exist")
+			u.log(ctx).Errorf("GetUserById:object with this id does not exist")
 			return nil, errors.Wrap(MyErrors.DoesNotExist, "getUserById")
 		} else {
-			u.logger.Errorf("Error while scanning for user:%s", err)
+			u.log(ctx).Errorf("Error while scanning for user:%s", err)
 			return nil, fmt.Errorf("getUserById: repository error:%w", err)
 		}
 	}
+	return user, nil
+}
+
+// userSelect builds the query of users with the list of their hobby ids and the expanded objects resolved by joins.
+func userSelect(expand models.Expand) squirrel.SelectBuilder {
+	s := squirrel.Select("users.id", "users.name", "users.email", "users.description", "users.country_id", "GROUP_CONCAT(users_hobbies.hobby_id) AS list")
+	if expand.Hobbies {
+		s = s.Column("JSON_ARRAYAGG(JSON_OBJECT('id', hobbies.id, 'name', hobbies.name)) AS hobby_list")
+	}
+	if expand.Country {
+		s = s.Columns("countries.name", "countries.full_name", "countries.english_name", "countries.alpha_2", "countries.alpha_3",
+			"countries.iso", "countries.location", "countries.location_precise", "countries.url")
+	}
+	return userFrom(s, expand).GroupBy("users.id")
+}
+
+// userFrom adds the tables the users are read from. The inner joins leave out some users, so the count of the users
+// is made with the same tables as their list.
+func userFrom(s squirrel.SelectBuilder, expand models.Expand) squirrel.SelectBuilder {
+	s = s.From("users").Join("users_hobbies on users.id = users_hobbies.user_id")
+	if expand.Hobbies {
+		s = s.Join("hobbies on hobbies.id = users_hobbies.hobby_id")
+	}
+	if expand.Country {
+		s = s.Join("countries on countries.id = users.country_id")
+	}
+	return s
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser scans a row of the query built by userSelect with the same expand.
+func scanUser(row scanner, expand models.Expand) (*models.ResponseUser, error) {
+	var user models.ResponseUser
+	var bytesHobby []byte
+	var hobbyList []byte
+	var country models.Country
+	dest := []interface{}{&user.Id, &user.Name, &user.Email, &user.Description, &user.CountryId, &bytesHobby}
+	if expand.Hobbies {
+		dest = append(dest, &hobbyList)
+	}
+	if expand.Country {
+		dest = append(dest, &country.Name, &country.FullName, &country.EnglishName, &country.Alpha2, &country.Alpha3,
+			&country.Iso, &country.Location, &country.LocationPrecise, &country.Url)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
 	strHobby := string(bytesHobby[:])
 	sliceHobby := strings.Split(strHobby, ",")
 	for _, n := range sliceHobby {
 		number, err := strconv.Atoi(n)
 		if err != nil {
-			u.logger.Errorf("Error while converting hobby`s id:%s", err)
-			return nil, fmt.Errorf("getUserById: Error while converting hobby`s id:%w", err)
+			return nil, fmt.Errorf("error while converting hobby`s id:%w", err)
 		}
 		user.Hobbies = append(user.Hobbies, number)
 	}
+	if expand.Hobbies {
+		if err := json.Unmarshal(hobbyList, &user.ExpandedHobbies); err != nil {
+			return nil, fmt.Errorf("error while unmarshaling hobbies:%w", err)
+		}
+	}
+	if expand.Country {
+		user.Country = &country
+	}
 	return &user, nil
 }
 
-func (u *UserRepository) GetUsers(options *models.Options) ([]models.ResponseUser, int, error) {
+func (u *UserRepository) GetUsers(ctx context.Context, options *models.Options) ([]models.ResponseUser, models.PageInfo, error) {
 	var users []models.ResponseUser
-	var sel squirrel.SelectBuilder
-	var pages int
-	s := squirrel.Select("users.id, users.name, users.email, users.description, users.country_id, GROUP_CONCAT(users_hobbies.hobby_id) AS list").From("users").
-		Join("users_hobbies on users.id = users_hobbies.user_id").GroupBy("users.id")
-	if options.Page != 0 && options.Limit != 0 {
-		sel = s.Limit(options.Limit).Offset((options.Page - 1) * options.Limit).OrderBy("users.id")
-	} else {
-		sel = s
-		pages = 1
+	var page models.PageInfo
+	sort := withTiebreaker(options.Sort, "id")
+	limit := options.Limit
+	if options.Keyset && limit == 0 {
+		limit = defaultCursorLimit
+	}
+	sel := userSelect(options.Expand)
+	switch {
+	case options.Keyset:
+		values, err := decodeCursor(options.Cursor, sort)
+		if err != nil {
+			u.log(ctx).Errorf("GetUsers: %s", err)
+			return nil, page, errors.Wrap(MyErrors.InvalidCursor, "getUsers")
+		}
+		if values != nil {
+			after, err := keysetCondition(sort, models.UserSortColumns, values)
+			if err != nil {
+				u.log(ctx).Errorf("GetUsers: %s", err)
+				return nil, page, fmt.Errorf("getUsers: %w", err)
+			}
+			sel = sel.Where(after)
+		}
+		sel = sel.Limit(limit + 1)
+	case options.Page != 0 && options.Limit != 0:
+		sel = sel.Limit(options.Limit).Offset((options.Page - 1) * options.Limit)
+	default:
+		page.Pages = 1
+	}
+	if page.Pages != 1 || len(options.Sort) != 0 {
+		order, err := orderBy(sort, models.UserSortColumns)
+		if err != nil {
+			u.log(ctx).Errorf("GetUsers: %s", err)
+			return nil, page, fmt.Errorf("getUsers: %w", err)
+		}
+		sel = sel.OrderBy(order...)
 	}
 	query, args, err := sel.ToSql()
 	if err != nil {
-		u.logger.Errorf("GetUsers: can not builds the query into a SQL:%s", err)
-		return nil, 0, fmt.Errorf("getUsers: can not builds the query into a SQL:%s", err)
+		u.log(ctx).Errorf("GetUsers: can not builds the query into a SQL:%s", err)
+		return nil, page, fmt.Errorf("getUsers: can not builds the query into a SQL:%w", err)
 	}
-	rows, err := u.db.Query(query, args...)
+	rows, err := u.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		u.logger.Errorf("GetUsers: can not executes a query:%s", err)
-		return nil, 0, fmt.Errorf("getUsers: can not executes a query:%s", err)
+		u.log(ctx).Errorf("GetUsers: can not executes a query:%s", err)
+		return nil, page, fmt.Errorf("getUsers: can not executes a query:%w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var bytesHobby []byte
-		var user models.ResponseUser
-		if err := rows.Scan(&user.Id, &user.Name, &user.Email, &user.Description, &user.CountryId, &bytesHobby); err != nil {
-			u.logger.Errorf("Error while scanning for user:%s", err)
-			return nil, 0, fmt.Errorf("getUsers:repository error:%w", err)
+		user, err := scanUser(rows, options.Expand)
+		if err != nil {
+			u.log(ctx).Errorf("Error while scanning for user:%s", err)
+			return nil, page, fmt.Errorf("getUsers:repository error:%w", err)
 		}
-		strHobby := string(bytesHobby[:])
-		sliceHobby := strings.Split(strHobby, ",")
-		for _, n := range sliceHobby {
-			number, err := strconv.Atoi(n)
+		users = append(users, *user)
+	}
+
+	if options.Keyset {
+		if uint64(len(users)) > limit {
+			users = users[:limit]
+			last := users[len(users)-1]
+			var values []interface{}
+			for _, field := range sort {
+				values = append(values, userField(last, field.Field))
+			}
+			page.NextCursor, err = encodeCursor(sort, values)
 			if err != nil {
-				u.logger.Errorf("Error while converting hobby`s id:%s", err)
-				return nil, 0, fmt.Errorf("getUsers: Error while converting hobby`s id:%w", err)
+				u.log(ctx).Errorf("GetUsers: %s", err)
+				return nil, page, fmt.Errorf("getUsers: %w", err)
 			}
-			user.Hobbies = append(user.Hobbies, number)
 		}
-		users = append(users, user)
+		return users, page, nil
+	}
+	if page.Pages == 1 {
+		page.Total = len(users)
+		return users, page, nil
+	}
+	query, args, err = userFrom(squirrel.Select("COUNT(DISTINCT users.id)"), options.Expand).ToSql()
+	if err != nil {
+		u.log(ctx).Errorf("GetUsers: can not builds the count query into a SQL:%s", err)
+		return nil, page, fmt.Errorf("getUsers: can not builds the count query into a SQL:%w", err)
+	}
+	row := u.db.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(&page.Total); err != nil {
+		u.log(ctx).Errorf("Error while scanning for total:%s", err)
+		return nil, page, fmt.Errorf("error while scanning for total:%w", err)
 	}
+	page.Pages = (page.Total + int(options.Limit) - 1) / int(options.Limit)
+	return users, page, nil
+}
 
-	if pages != 1 {
-		query = "SELECT CEILING(COUNT(*)/?) FROM users"
-		row := u.db.QueryRow(query, options.Limit)
-		if err := row.Scan(&pages); err != nil {
-			u.logger.Errorf("Error while scanning for pages:%s", err)
-			return nil, 0, fmt.Errorf("error while scanning for pages:%s", err)
-		}
+// userField returns the value of the user field with the given JSON name.
+func userField(user models.ResponseUser, field string) interface{} {
+	switch field {
+	case "id":
+		return user.Id
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	case "country_id":
+		return user.CountryId
 	}
-	return users, pages, nil
+	return nil
 }
 
-func (u *UserRepository) ChangeUser(user *models.User, userId int) error {
-	transaction, err := u.db.Begin()
+func (u *UserRepository) ChangeUser(ctx context.Context, user *models.User, userId int) error {
+	transaction, err := u.db.BeginTx(ctx, nil)
 	if err != nil {
-		u.logger.Errorf("ChangeUser: can not starts transaction:%s", err)
+		u.log(ctx).Errorf("ChangeUser: can not starts transaction:%s", err)
 		return fmt.Errorf("changeUser: can not starts transaction:%w", err)
 	}
 	defer transaction.Rollback()
 
-	hobbiesId, err := CheckUserData(transaction, user)
+	hobbiesId, err := CheckUserData(ctx, transaction, user)
 	if err != nil {
-		u.logger.Errorf("ChangeUser: error while checking user data:%s", err)
+		u.log(ctx).Errorf("ChangeUser: error while checking user data:%s", err)
 		return fmt.Errorf("сhangeUser: error while checking user data:%w", err)
 	}
-	if len(hobbiesId) == 0 {
-		u.logger.Errorf("ChangeUser: there are no correct hobbies for the user")
-		return fmt.Errorf("сhangeUser: there are no correct hobbies for the user")
-	}
 	user.Hobbies = hobbiesId
 
 	query := "UPDATE users SET name = ?, email = ?, description = ?, country_id = ? WHERE id = ?"
-	result, err := transaction.Exec(query, user.Name, user.Email, user.Description, user.CountryId, userId)
+	result, err := transaction.ExecContext(ctx, query, user.Name, user.Email, user.Description, user.CountryId, userId)
 	if err != nil {
-		u.logger.Errorf("ChangeUser: error while updating user:%s", err)
-		return fmt.Errorf("changeUser: error while updating user:%w", err)
+		u.log(ctx).Errorf("ChangeUser: error while updating user:%s", err)
+		return fmt.Errorf("changeUser: error while updating user:%w", constraintError(err))
 	}
 	numberRows, err := result.RowsAffected()
 	if err != nil {
-		u.logger.Errorf("Error while getting number affected rows:%s", err)
-		return fmt.Errorf("changeUser: error while getting number affected rows:%s", err)
+		u.log(ctx).Errorf("Error while getting number affected rows:%s", err)
+		return fmt.Errorf("changeUser: error while getting number affected rows:%w", err)
 	}
 	if numberRows == 0 {
-		u.logger.Errorf("ChangeUser:object with this id does not exist")
+		u.log(ctx).Errorf("ChangeUser:object with this id does not exist")
 		return errors.Wrap(MyErrors.DoesNotExist, "changeUser")
 	}
 
 	query = "DELETE FROM users_hobbies WHERE user_id = ?"
-	_, err = transaction.Exec(query, userId)
+	_, err = transaction.ExecContext(ctx, query, userId)
 	if err != nil {
-		u.logger.Errorf("ChangeUser: error while deleting bound relations:%s", err)
+		u.log(ctx).Errorf("ChangeUser: error while deleting bound relations:%s", err)
 		return fmt.Errorf("changeUser: error whiledeleting bound relations:%w", err)
 	}
 
@@ -203,65 +308,179 @@ func (u *UserRepository) ChangeUser(user *models.User, userId int) error {
 		query += `(?,?),`
 	}
 	query = query[:len(query)-1]
-	_, err = transaction.Exec(query, values...)
+	_, err = transaction.ExecContext(ctx, query, values...)
 	if err != nil {
-		u.logger.Errorf("ChangeUser: error while insert users_hobbies:%s", err)
+		u.log(ctx).Errorf("ChangeUser: error while insert users_hobbies:%s", err)
 		return fmt.Errorf("changeUser: error while insert users_hobbies:%w", err)
 	}
 	return transaction.Commit()
 }
 
-func (u *UserRepository) DeleteUser(userId int) error {
+func (u *UserRepository) PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error {
+	var query string
+	transaction, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		u.log(ctx).Errorf("PatchUser: can not starts transaction:%s", err)
+		return fmt.Errorf("patchUser: can not starts transaction:%w", err)
+	}
+	defer transaction.Rollback()
+	if err := lockUser(ctx, transaction, userId); err != nil {
+		u.log(ctx).Errorf("PatchUser: %s", err)
+		return errors.Wrap(err, "patchUser")
+	}
+
+	hobbiesId, err := checkReferences(ctx, transaction, patch.CountryId, patch.Hobbies)
+	if err != nil {
+		u.log(ctx).Errorf("PatchUser: error while checking user data:%s", err)
+		return fmt.Errorf("patchUser: error while checking user data:%w", err)
+	}
+
+	set := make(map[string]interface{})
+	if patch.Name != nil {
+		set["name"] = *patch.Name
+	}
+	if patch.Email != nil {
+		set["email"] = *patch.Email
+	}
+	if patch.Description != nil {
+		set["description"] = *patch.Description
+	}
+	if patch.CountryId != nil {
+		set["country_id"] = *patch.CountryId
+	}
+	if len(set) != 0 {
+		query, args, err := squirrel.Update("users").SetMap(set).Where(squirrel.Eq{"id": userId}).ToSql()
+		if err != nil {
+			u.log(ctx).Errorf("PatchUser: can not builds the query into a SQL:%s", err)
+			return fmt.Errorf("patchUser: can not builds the query into a SQL:%w", err)
+		}
+		if _, err = transaction.ExecContext(ctx, query, args...); err != nil {
+			u.log(ctx).Errorf("PatchUser: error while updating user:%s", err)
+			return fmt.Errorf("patchUser: error while updating user:%w", constraintError(err))
+		}
+	}
+
+	if patch.Hobbies != nil {
+		query = "DELETE FROM users_hobbies WHERE user_id = ?"
+		if _, err = transaction.ExecContext(ctx, query, userId); err != nil {
+			u.log(ctx).Errorf("PatchUser: error while deleting bound relations:%s", err)
+			return fmt.Errorf("patchUser: error while deleting bound relations:%w", err)
+		}
+		query = "INSERT INTO users_hobbies (user_id, hobby_id) values "
+		var values []interface{}
+		for _, s := range hobbiesId {
+			values = append(values, userId, s)
+			query += `(?,?),`
+		}
+		query = query[:len(query)-1]
+		if _, err = transaction.ExecContext(ctx, query, values...); err != nil {
+			u.log(ctx).Errorf("PatchUser: error while insert users_hobbies:%s", err)
+			return fmt.Errorf("patchUser: error while insert users_hobbies:%w", err)
+		}
+	}
+	return transaction.Commit()
+}
+
+func (u *UserRepository) DeleteUser(ctx context.Context, userId int) error {
 	query := "DELETE from users WHERE id = ?"
-	result, err := u.db.Exec(query, userId)
+	result, err := u.db.ExecContext(ctx, query, userId)
 	if err != nil {
-		u.logger.Errorf("DeleteUser: can not executes a query:%s", err)
-		return fmt.Errorf("deleteUser: can not executes a query:%s", err)
+		u.log(ctx).Errorf("DeleteUser: can not executes a query:%s", err)
+		return fmt.Errorf("deleteUser: can not executes a query:%w", err)
 	}
 	numberRows, err := result.RowsAffected()
 	if err != nil {
-		u.logger.Errorf("Error while getting number affected rows:%s", err)
-		return fmt.Errorf("deleteUser: error while getting number affected rows:%s", err)
+		u.log(ctx).Errorf("Error while getting number affected rows:%s", err)
+		return fmt.Errorf("deleteUser: error while getting number affected rows:%w", err)
 	}
 	if numberRows == 0 {
-		u.logger.Errorf("DeleteUser:object with this id does not exist")
+		u.log(ctx).Errorf("DeleteUser:object with this id does not exist")
 		return errors.Wrap(MyErrors.DoesNotExist, "deleteUser")
 	}
 	return nil
 }
 
-func CheckUserData(tr *sql.Tx, user *models.User) ([]int, error) {
-	var exist bool
+// CheckUserData checks that the country and all the hobbies of the user exist and returns the hobbies
+// without repeated ids. Every missing object is reported in a *MyErrors.ValidationError.
+func CheckUserData(ctx context.Context, tr *sql.Tx, user *models.User) ([]int, error) {
+	return checkReferences(ctx, tr, &user.CountryId, &user.Hobbies)
+}
+
+// checkReferences checks the country and the hobbies that are not nil.
+func checkReferences(ctx context.Context, tr *sql.Tx, countryId *int, hobbies *[]int) ([]int, error) {
+	var validation MyErrors.ValidationError
 	var hobbiesId []int
-	var userHobbies []int
+	if countryId != nil {
+		exist, err := countryExists(ctx, tr, *countryId)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			validation.CountryId = countryId
+		}
+	}
+	if hobbies != nil {
+		var err error
+		hobbiesId, validation.Hobbies, err = splitHobbies(ctx, tr, *hobbies)
+		if err != nil {
+			return nil, err
+		}
+		validation.NoHobbies = len(*hobbies) == 0
+	}
+	if validation.Failed() {
+		return nil, &validation
+	}
+	return hobbiesId, nil
+}
+
+func countryExists(ctx context.Context, tr *sql.Tx, countryId int) (bool, error) {
+	var exist bool
 	query := "SELECT EXISTS (SELECT 1 FROM countries WHERE id = ?)"
-	row := tr.QueryRow(query, user.CountryId)
+	row := tr.QueryRowContext(ctx, query, countryId)
 	if err := row.Scan(&exist); err != nil {
-		return nil, err
+		return false, fmt.Errorf("checkUserData: error while scanning for existing country:%w", err)
 	}
-	if !exist {
-		return nil, MyErrors.DoesNotExist
+	return exist, nil
+}
+
+// splitHobbies splits the ids into the ids of existing hobbies, without repeats, and the ids that do not exist.
+func splitHobbies(ctx context.Context, tr *sql.Tx, ids []int) ([]int, []int, error) {
+	var existing, missing []int
+	if len(ids) == 0 {
+		return nil, nil, nil
 	}
-	query = "SELECT id FROM hobbies"
-	rows, err := tr.Query(query)
+	query, args, err := squirrel.Select("id").From("hobbies").Where(squirrel.Eq{"id": ids}).ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("checkUserData: can not executes a query:%s", err)
+		return nil, nil, fmt.Errorf("checkUserData: can not builds the query into a SQL:%w", err)
+	}
+	rows, err := tr.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("checkUserData: can not executes a query:%w", err)
 	}
 	defer rows.Close()
+	found := make(map[int]bool)
 	for rows.Next() {
 		var id int
 		if err := rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("checkUserData:repository error:%w", err)
+			return nil, nil, fmt.Errorf("checkUserData:repository error:%w", err)
 		}
-		hobbiesId = append(hobbiesId, id)
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("checkUserData:repository error:%w", err)
 	}
 
-	for _, id := range user.Hobbies {
-		for _, hobby := range hobbiesId {
-			if hobby == id {
-				userHobbies = append(userHobbies, id)
-			}
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if found[id] {
+			existing = append(existing, id)
+		} else {
+			missing = append(missing, id)
 		}
 	}
-	return userHobbies, nil
+	return existing, missing, nil
 }
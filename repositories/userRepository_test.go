@@ -1,11 +1,14 @@
 package repositories
 
 import (
+	"context"
 	"database/sql/driver"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"tranee_service/MyErrors"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 )
@@ -20,11 +23,13 @@ func TestCreateUser(t *testing.T) {
 	r := NewRepository(db, logger)
 
 	testTable := []struct {
-		name           string
-		mock           func(user *models.User)
-		inputUser      *models.User
-		expectedResult int
-		expectedError  bool
+		name               string
+		mock               func(user *models.User)
+		inputUser          *models.User
+		expectedResult     int
+		expectedError      bool
+		expectedValidation *MyErrors.ValidationError
+		expectedConflict   string
 	}{
 		{
 			name: "OK",
@@ -37,6 +42,10 @@ func TestCreateUser(t *testing.T) {
 			},
 			mock: func(user *models.User) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(true))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 				result := sqlmock.NewResult(1, 1)
 				mock.ExpectExec("INSERT INTO users").
 					WithArgs(user.Name, user.Email, user.Description, user.CountryId).
@@ -59,6 +68,10 @@ func TestCreateUser(t *testing.T) {
 			},
 			mock: func(user *models.User) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(true))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 				result := sqlmock.NewResult(1, 1)
 				mock.ExpectExec("INSERT INTO users").
 					WithArgs(user.Name, user.Email, user.Description, user.CountryId).
@@ -70,13 +83,71 @@ func TestCreateUser(t *testing.T) {
 			expectedResult: 0,
 			expectedError:  true,
 		},
+		{
+			name: "Country and hobbies do not exist",
+			inputUser: &models.User{
+				Name:        "testName",
+				Email:       "test@test.ru",
+				Description: "test desc",
+				CountryId:   7,
+				Hobbies:     []int{1, 3, 4},
+			},
+			mock: func(user *models.User) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(false))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 3, 4).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			expectedResult: 0,
+			expectedError:  true,
+			expectedValidation: &MyErrors.ValidationError{
+				CountryId: intPointer(7),
+				Hobbies:   []int{3, 4},
+			},
+		},
+		{
+			name: "User with such email already exists",
+			inputUser: &models.User{
+				Name:        "testName",
+				Email:       "test@test.ru",
+				Description: "test desc",
+				CountryId:   1,
+				Hobbies:     []int{1, 2},
+			},
+			mock: func(user *models.User) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(true))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+				mock.ExpectExec("INSERT INTO users").
+					WithArgs(user.Name, user.Email, user.Description, user.CountryId).
+					WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'test@test.ru' for key 'users.email'"})
+				mock.ExpectRollback()
+			},
+			expectedResult:   0,
+			expectedError:    true,
+			expectedConflict: "email",
+		},
 	}
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputUser)
-			id, err := r.CreateUser(tt.inputUser)
+			id, err := r.CreateUser(context.Background(), tt.inputUser)
 			if tt.expectedError {
 				assert.Error(t, err)
+				if tt.expectedValidation != nil {
+					var validation *MyErrors.ValidationError
+					assert.ErrorAs(t, err, &validation)
+					assert.Equal(t, tt.expectedValidation, validation)
+				}
+				if tt.expectedConflict != "" {
+					var conflict *MyErrors.ConflictError
+					assert.ErrorAs(t, err, &conflict)
+					assert.Equal(t, tt.expectedConflict, conflict.Field)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedResult, id)
@@ -114,6 +185,10 @@ func TestChangeUser(t *testing.T) {
 			inputId: 1,
 			mock: func(user *models.User, userId int) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(true))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 				result := sqlmock.NewResult(1, 1)
 				mock.ExpectExec("UPDATE users SET").
 					WithArgs(user.Name, user.Email, user.Description, user.CountryId, userId).
@@ -138,6 +213,10 @@ func TestChangeUser(t *testing.T) {
 			inputId: 1,
 			mock: func(user *models.User, userId int) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(true))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 				result := sqlmock.NewResult(0, 0)
 				mock.ExpectExec("UPDATE users SET").
 					WithArgs(user.Name, user.Email, user.Description, user.CountryId, userId).
@@ -158,6 +237,10 @@ func TestChangeUser(t *testing.T) {
 			inputId: 1,
 			mock: func(user *models.User, userId int) {
 				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(user.CountryId).
+					WillReturnRows(sqlmock.NewRows([]string{"exist"}).AddRow(true))
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 				result := sqlmock.NewResult(1, 1)
 				mock.ExpectExec("UPDATE users SET").
 					WithArgs(user.Name, user.Email, user.Description, user.CountryId, userId).
@@ -174,7 +257,114 @@ func TestChangeUser(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputUser, tt.inputId)
-			err := r.ChangeUser(tt.inputUser, tt.inputId)
+			err := r.ChangeUser(context.Background(), tt.inputUser, tt.inputId)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	logger := logging.GetLoggerLogrus()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+	r := NewRepository(db, logger)
+	description := "new desc"
+	countryId := 2
+	hobbies := []int{2, 5}
+
+	testTable := []struct {
+		name          string
+		mock          func(userId int)
+		inputPatch    *models.UserPatch
+		inputId       int
+		expectedError bool
+	}{
+		{
+			name:       "OK only description",
+			inputPatch: &models.UserPatch{Description: &description},
+			inputId:    1,
+			mock: func(userId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(userId)
+				mock.ExpectQuery("SELECT id FROM users").WithArgs(userId).WillReturnRows(rows)
+				mock.ExpectExec(`UPDATE users SET description = \? WHERE id = \?`).
+					WithArgs(description, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedError: false,
+		},
+		{
+			name:       "OK country and hobbies",
+			inputPatch: &models.UserPatch{CountryId: &countryId, Hobbies: &hobbies},
+			inputId:    1,
+			mock: func(userId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(userId)
+				mock.ExpectQuery("SELECT id FROM users").WithArgs(userId).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"exist"}).AddRow(true)
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(countryId).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(5)
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(2, 5).WillReturnRows(rows)
+				mock.ExpectExec(`UPDATE users SET country_id = \? WHERE id = \?`).
+					WithArgs(countryId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM users_hobbies").WithArgs(userId).WillReturnResult(driver.ResultNoRows)
+				mock.ExpectExec("INSERT INTO users_hobbies").WithArgs(userId, 2, userId, 5).WillReturnResult(driver.ResultNoRows)
+				mock.ExpectCommit()
+			},
+			expectedError: false,
+		},
+		{
+			name:       "Hobbies do not exist",
+			inputPatch: &models.UserPatch{Description: &description, Hobbies: &hobbies},
+			inputId:    1,
+			mock: func(userId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(userId)
+				mock.ExpectQuery("SELECT id FROM users").WithArgs(userId).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"id"}).AddRow(2)
+				mock.ExpectQuery("SELECT id FROM hobbies").WithArgs(2, 5).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+		{
+			name:       "User with such Id does not exist",
+			inputPatch: &models.UserPatch{Description: &description},
+			inputId:    1,
+			mock: func(userId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"})
+				mock.ExpectQuery("SELECT id FROM users").WithArgs(userId).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+		{
+			name:       "Data base error",
+			inputPatch: &models.UserPatch{Description: &description},
+			inputId:    1,
+			mock: func(userId int) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(userId)
+				mock.ExpectQuery("SELECT id FROM users").WithArgs(userId).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE users SET").WillReturnError(errors.New("data base error"))
+				mock.ExpectRollback()
+			},
+			expectedError: true,
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock(tt.inputId)
+			err := r.PatchUser(context.Background(), tt.inputPatch, tt.inputId)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -232,7 +422,7 @@ func TestDeleteUser(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputId)
-			err := r.DeleteUser(tt.inputId)
+			err := r.DeleteUser(context.Background(), tt.inputId)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -256,6 +446,7 @@ func TestGetUserById(t *testing.T) {
 		name           string
 		mock           func(userId int)
 		inputId        int
+		inputExpand    models.Expand
 		expectedResult *models.ResponseUser
 		expectedError  bool
 	}{
@@ -278,6 +469,39 @@ func TestGetUserById(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name:        "OK with expand",
+			inputId:     1,
+			inputExpand: models.Expand{Hobbies: true, Country: true},
+			mock: func(userId int) {
+				rows := sqlmock.NewRows([]string{"id", "name", "email", "description", "countryId", "list", "hobby_list",
+					"name", "full_name", "english_name", "alpha_2", "alpha_3", "iso", "location", "location_precise", "url"}).
+					AddRow(1, "test name", "test email", "test desc", 1, []byte("1"+","+"2"), []byte(`[{"id": 1, "name": "test hobby"}, {"id": 2, "name": "test hobby2"}]`),
+						"test country", "test full name", "test english name", "TT", "TTT", 1000, "test location", "test location precise", "")
+				mock.ExpectQuery(`SELECT users.id, .*JSON_ARRAYAGG.* countries.url FROM users JOIN users_hobbies .* JOIN hobbies .* JOIN countries`).WithArgs(userId).
+					WillReturnRows(rows)
+			},
+			expectedResult: &models.ResponseUser{
+				Id:              1,
+				Name:            "test name",
+				Email:           "test email",
+				Description:     "test desc",
+				CountryId:       1,
+				Hobbies:         []int{1, 2},
+				ExpandedHobbies: []models.ResponseHobby{{Id: 1, Name: "test hobby"}, {Id: 2, Name: "test hobby2"}},
+				Country: &models.Country{
+					Name:            "test country",
+					FullName:        "test full name",
+					EnglishName:     "test english name",
+					Alpha2:          "TT",
+					Alpha3:          "TTT",
+					Iso:             1000,
+					Location:        "test location",
+					LocationPrecise: "test location precise",
+				},
+			},
+			expectedError: false,
+		},
 		{
 			name:    "Data base error",
 			inputId: 1,
@@ -290,7 +514,7 @@ func TestGetUserById(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputId)
-			country, err := r.GetUserById(tt.inputId)
+			country, err := r.GetUserById(context.Background(), tt.inputId, tt.inputExpand)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -316,6 +540,7 @@ func TestGetUsers(t *testing.T) {
 		mock           func(options *models.Options)
 		inputOptions   *models.Options
 		expectedResult []models.ResponseUser
+		expectedPage   models.PageInfo
 		expectedError  bool
 	}{
 		{
@@ -329,8 +554,9 @@ func TestGetUsers(t *testing.T) {
 					AddRow(1, "test name", "test email", "test desc", 1, []byte("1"+","+"2")).
 					AddRow(2, "test name2", "test email2", "test desc2", 1, []byte("1"+","+"2"))
 				mock.ExpectQuery("SELECT users.id, ").WillReturnRows(rows)
-				rows = sqlmock.NewRows([]string{"pages"}).AddRow(1)
-				mock.ExpectQuery("SELECT CEILING").WithArgs(options.Limit).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"total"}).AddRow(2)
+				mock.ExpectQuery(`SELECT COUNT\(DISTINCT users.id\) FROM users JOIN users_hobbies on users.id = users_hobbies.user_id$`).
+					WillReturnRows(rows)
 			},
 			expectedResult: []models.ResponseUser{
 				{
@@ -350,6 +576,7 @@ func TestGetUsers(t *testing.T) {
 					Hobbies:     []int{1, 2},
 				},
 			},
+			expectedPage:  models.PageInfo{Total: 2, Pages: 1},
 			expectedError: false,
 		},
 		{
@@ -382,8 +609,105 @@ func TestGetUsers(t *testing.T) {
 					Hobbies:     []int{1, 2},
 				},
 			},
+			expectedPage:  models.PageInfo{Total: 2, Pages: 1},
 			expectedError: false,
 		},
+		{
+			name: "OK with sort",
+			inputOptions: &models.Options{
+				Page:  1,
+				Limit: 2,
+				Sort:  []models.SortField{{Field: "name"}, {Field: "country_id", Desc: true}},
+			},
+			mock: func(options *models.Options) {
+				rows := sqlmock.NewRows([]string{"id", "name", "email", "description", "countryId", "list"}).
+					AddRow(1, "test name", "test email", "test desc", 1, []byte("1"+","+"2"))
+				mock.ExpectQuery(`SELECT users.id, .* ORDER BY users.name, users.country_id DESC, users.id LIMIT 2 OFFSET 0`).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"total"}).AddRow(2)
+				mock.ExpectQuery("SELECT COUNT").WillReturnRows(rows)
+			},
+			expectedResult: []models.ResponseUser{
+				{
+					Id:          1,
+					Name:        "test name",
+					Email:       "test email",
+					Description: "test desc",
+					CountryId:   1,
+					Hobbies:     []int{1, 2},
+				},
+			},
+			expectedPage:  models.PageInfo{Total: 2, Pages: 1},
+			expectedError: false,
+		},
+		{
+			name: "OK with cursor first page",
+			inputOptions: &models.Options{
+				Limit:  1,
+				Keyset: true,
+			},
+			mock: func(options *models.Options) {
+				rows := sqlmock.NewRows([]string{"id", "name", "email", "description", "countryId", "list"}).
+					AddRow(1, "test name", "test email", "test desc", 1, []byte("1"+","+"2")).
+					AddRow(2, "test name2", "test email2", "test desc2", 1, []byte("1"+","+"2"))
+				mock.ExpectQuery(`SELECT users.id, .* GROUP BY users.id ORDER BY users.id LIMIT 2`).WillReturnRows(rows)
+			},
+			expectedResult: []models.ResponseUser{
+				{
+					Id:          1,
+					Name:        "test name",
+					Email:       "test email",
+					Description: "test desc",
+					CountryId:   1,
+					Hobbies:     []int{1, 2},
+				},
+			},
+			expectedPage:  models.PageInfo{NextCursor: mustEncodeCursor([]models.SortField{{Field: "id"}}, []interface{}{1})},
+			expectedError: false,
+		},
+		{
+			name: "OK with cursor next page",
+			inputOptions: &models.Options{
+				Limit:  1,
+				Sort:   []models.SortField{{Field: "name", Desc: true}},
+				Keyset: true,
+				Cursor: mustEncodeCursor([]models.SortField{{Field: "name", Desc: true}, {Field: "id"}}, []interface{}{"test name", 1}),
+			},
+			mock: func(options *models.Options) {
+				rows := sqlmock.NewRows([]string{"id", "name", "email", "description", "countryId", "list"}).
+					AddRow(2, "test name", "test email2", "test desc2", 1, []byte("1"))
+				mock.ExpectQuery(`SELECT users.id, .* WHERE \(\(users.name < \?\) OR \(users.name = \? AND users.id > \?\)\) GROUP BY users.id ORDER BY users.name DESC, users.id LIMIT 2`).
+					WithArgs("test name", "test name", 1).WillReturnRows(rows)
+			},
+			expectedResult: []models.ResponseUser{
+				{
+					Id:          2,
+					Name:        "test name",
+					Email:       "test email2",
+					Description: "test desc2",
+					CountryId:   1,
+					Hobbies:     []int{1},
+				},
+			},
+			expectedError: false,
+		},
+		{
+			name: "Cursor created for another sort",
+			inputOptions: &models.Options{
+				Limit:  1,
+				Keyset: true,
+				Cursor: mustEncodeCursor([]models.SortField{{Field: "name"}, {Field: "id"}}, []interface{}{"test name", 1}),
+			},
+			mock:          func(options *models.Options) {},
+			expectedError: true,
+		},
+		{
+			name: "Not allowed sort field",
+			inputOptions: &models.Options{
+				Sort: []models.SortField{{Field: "description"}},
+			},
+			mock:          func(options *models.Options) {},
+			expectedError: true,
+		},
 		{
 			name: "Data base error",
 			inputOptions: &models.Options{
@@ -399,14 +723,27 @@ func TestGetUsers(t *testing.T) {
 	for _, tt := range testTable {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock(tt.inputOptions)
-			countries, _, err := r.GetUsers(tt.inputOptions)
+			countries, page, err := r.GetUsers(context.Background(), tt.inputOptions)
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedResult, countries)
+				assert.Equal(t, tt.expectedPage, page)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+func mustEncodeCursor(sort []models.SortField, values []interface{}) string {
+	token, err := encodeCursor(sort, values)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+func intPointer(i int) *int {
+	return &i
+}
@@ -0,0 +1,23 @@
+-- Merges the countries that share alpha_2, so that the migration 00003 can make alpha_2 unique. The country with
+-- the lowest id is kept, the users of the others are moved to it and the others are deleted. It is run by hand
+-- after a backup: the deleted countries can not be restored.
+
+-- The countries that are going to be deleted and the country their users are moved to.
+SELECT duplicate.id, duplicate.alpha_2, duplicate.name, original.id AS kept_id,
+       (SELECT COUNT(*) FROM users WHERE users.country_id = duplicate.id) AS users
+FROM countries duplicate
+    JOIN (SELECT alpha_2, MIN(id) AS id FROM countries GROUP BY alpha_2) original ON duplicate.alpha_2 = original.alpha_2
+WHERE duplicate.id > original.id;
+
+START TRANSACTION;
+
+UPDATE users
+    JOIN countries duplicate ON users.country_id = duplicate.id
+    JOIN (SELECT alpha_2, MIN(id) AS id FROM countries GROUP BY alpha_2) original ON duplicate.alpha_2 = original.alpha_2
+SET users.country_id = original.id
+WHERE duplicate.id > original.id;
+
+DELETE duplicate FROM countries duplicate
+    JOIN countries original ON duplicate.alpha_2 = original.alpha_2 AND duplicate.id > original.id;
+
+COMMIT;
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"tranee_service/MyErrors"
+)
+
+// background runs the jobs that outlive the request that started them, so that the shutdown can wait for them.
+type background struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func newBackground() *background {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &background{ctx: ctx, cancel: cancel}
+}
+
+// run starts the job, unless stop has been called: the jobs started then would not be waited for, and
+// MyErrors.ShuttingDown is returned.
+func (b *background) run(job func(ctx context.Context)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return MyErrors.ShuttingDown
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		job(b.ctx)
+	}()
+	return nil
+}
+
+// stop waits for the running jobs. If ctx is done first, the jobs are cancelled and the error of ctx is returned
+// once they have returned.
+func (b *background) stop(ctx context.Context) error {
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+	finished := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		<-finished
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBackgroundStop(t *testing.T) {
+	testTable := []struct {
+		name              string
+		jobDuration       time.Duration
+		gracePeriod       time.Duration
+		expectedError     error
+		expectedCancelled bool
+	}{
+		{
+			name:              "Job finishes within grace period",
+			jobDuration:       10 * time.Millisecond,
+			gracePeriod:       time.Second,
+			expectedError:     nil,
+			expectedCancelled: false,
+		},
+		{
+			name:              "Job is cancelled after grace period",
+			jobDuration:       time.Minute,
+			gracePeriod:       10 * time.Millisecond,
+			expectedError:     context.DeadlineExceeded,
+			expectedCancelled: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			jobs := newBackground()
+			cancelled := false
+			jobs.run(func(ctx context.Context) {
+				select {
+				case <-time.After(testCase.jobDuration):
+				case <-ctx.Done():
+					cancelled = true
+				}
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), testCase.gracePeriod)
+			defer cancel()
+			err := jobs.stop(ctx)
+
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedCancelled, cancelled)
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/tidwall/gjson"
-	"io"
-	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/blobstore"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 	"tranee_service/repositories"
@@ -13,67 +17,241 @@ import (
 type CountryService struct {
 	repository *repositories.Repository
 	logger     logging.Logger
+	state      *State
+	observer   LoadObserver
+	fetcher    *flagFetcher
+	downloader *flagDownloader
+	thumbnails *thumbnailer
+	store      blobstore.Store
+	// localDirs are the directories of the local flag providers, their files are the only ones the urls
+	// can point at.
+	localDirs []string
 }
 
-func NewCountryService(repository *repositories.Repository, logger logging.Logger) *CountryService {
-	return &CountryService{repository: repository, logger: logger}
+func NewCountryService(repository *repositories.Repository, logger logging.Logger, state *State, observer LoadObserver,
+	provider FlagProvider, config FetchConfig, store blobstore.Store) *CountryService {
+	dirs := localDirs(provider)
+	return &CountryService{
+		repository: repository,
+		logger:     logger,
+		state:      state,
+		observer:   observer,
+		fetcher:    newFlagFetcher(config, provider),
+		downloader: &flagDownloader{client: newImageClient(dirs), store: store},
+		thumbnails: &thumbnailer{store: store},
+		store:      store,
+		localDirs:  dirs,
+	}
+}
+
+func (c *CountryService) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, c.logger)
 }
 
-func (c *CountryService) GetOneCountry(id string) (*models.Country, error) {
-	if err := c.repository.CheckCountryId(id); err != nil {
+// SeedCountries saves the countries read from the CSV file. The service is not ready until they are saved.
+func (c *CountryService) SeedCountries(ctx context.Context, countries []models.Country) error {
+	err := c.repository.SaveInitialCountries(ctx, countries)
+	c.state.seedFinished(err)
+	return err
+}
+
+func (c *CountryService) GetOneCountry(ctx context.Context, id string) (*models.Country, error) {
+	if err := c.repository.CheckCountryId(ctx, id); err != nil {
 		return nil, err
 	}
-	return c.repository.GetOneCountry(id)
+	return c.repository.GetOneCountry(ctx, id)
 }
 
-func (c *CountryService) GetCountries(filters *models.Filters) ([]models.Country, int, error) {
-	return c.repository.GetCountries(filters)
+func (c *CountryService) GetCountries(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error) {
+	return c.repository.GetCountries(ctx, filters)
 }
 
-func (c *CountryService) CreateCountry(country *models.ResponseCountry) (string, error) {
-	return c.repository.CreateCountry(country)
+func (c *CountryService) CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error) {
+	if err := c.checkUrl(country.Url); err != nil {
+		return "", err
+	}
+	return c.repository.CreateCountry(ctx, country)
 }
 
-func (c *CountryService) ChangeCountry(country *models.ResponseCountry, countryId string) error {
-	if err := c.repository.CheckCountryId(countryId); err != nil {
-		return err
+func (c *CountryService) ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error) {
+	if err := c.checkUrl(country.Url); err != nil {
+		return "", err
 	}
-	return c.repository.ChangeCountry(country, countryId)
+	return c.repository.ChangeCountry(ctx, country, countryId)
 }
 
-func (c *CountryService) DeleteCountry(countryId string) error {
-	return c.repository.DeleteCountry(countryId)
+func (c *CountryService) PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error) {
+	if patch.Url != nil {
+		if err := c.checkUrl(*patch.Url); err != nil {
+			return "", err
+		}
+	}
+	return c.repository.PatchCountry(ctx, patch, countryId)
 }
 
-func (c *CountryService) LoadImages() {
-	countries, _, err := c.repository.GetCountries(&models.Filters{
+// checkUrl accepts the file url only if it points into the directories of the local flag providers, so that
+// a country read from the API can be sent back with the url a provider has found.
+func (c *CountryService) checkUrl(flagUrl string) error {
+	parsed, err := url.Parse(flagUrl)
+	if err != nil || parsed.Scheme != "file" {
+		return nil
+	}
+	path := filepath.Clean(filepath.FromSlash(parsed.Path))
+	for _, dir := range c.localDirs {
+		if _, inside := relativePath(dir, path); inside {
+			return nil
+		}
+	}
+	return &MyErrors.ValidationError{FlagFile: true}
+}
+
+func (c *CountryService) DeleteCountry(ctx context.Context, countryId string) error {
+	return c.repository.DeleteCountry(ctx, countryId)
+}
+
+// GetFlag opens the saved image of the flag of the country, or its thumbnail when options.Width is set.
+// The SVG original is returned instead of the thumbnail to the clients that accept SVG. The caller closes
+// the content.
+func (c *CountryService) GetFlag(ctx context.Context, id string, options models.FlagOptions) (*models.Flag, error) {
+	country, err := c.GetOneCountry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	content, info, err := c.store.Open(ctx, flagKey(country.Alpha2))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil, MyErrors.FlagNotLoaded
+	}
+	if err != nil {
+		c.log(ctx).Errorf("GetFlag: error while opening flag of %s:%s", country.Alpha2, err)
+		return nil, fmt.Errorf("getFlag: error while opening flag of %s:%w", country.Alpha2, err)
+	}
+	if options.Width == 0 || options.AcceptSVG && info.ContentType == "image/svg+xml" {
+		return &models.Flag{Content: content, ContentType: info.ContentType, Hash: info.Hash, Updated: info.Updated}, nil
+	}
+	defer content.Close()
+	thumbnail, thumbnailInfo, err := c.thumbnails.open(ctx, country.Alpha2, options.Width, info, content)
+	if errors.Is(err, errNotResizable) {
+		c.log(ctx).Warnf("Flag of %s is served without resizing:%s", country.Alpha2, err)
+		return c.GetFlag(ctx, id, models.FlagOptions{})
+	}
+	if err != nil {
+		c.log(ctx).Errorf("GetFlag: error while making thumbnail of %s:%s", country.Alpha2, err)
+		return nil, fmt.Errorf("getFlag: error while making thumbnail of %s:%w", country.Alpha2, err)
+	}
+	return &models.Flag{Content: thumbnail, ContentType: thumbnailInfo.ContentType, Hash: thumbnailInfo.Hash,
+		Updated: thumbnailInfo.Updated}, nil
+}
+
+// LoadImages looks for the flags of the countries without them and saves the found ones, then downloads
+// the images of the flags into the store. progress is called with the state of the run every time a country
+// is checked. The returned error means that the found urls were not saved.
+func (c *CountryService) LoadImages(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+	run := models.LoadRun{Started: time.Now()}
+	update := func() {
+		c.state.setLastLoad(run)
+		if progress != nil {
+			progress(snapshot(run))
+		}
+	}
+	update()
+	defer func() {
+		finished := time.Now()
+		run.Finished = &finished
+		update()
+		c.observer.ObserveLoad(run)
+	}()
+	countries, _, err := c.repository.GetCountries(ctx, &models.Filters{
 		Page:  0,
 		Limit: 0,
 		Flag:  true,
 	})
 	if err != nil {
-		c.logger.Errorf(err.Error())
+		c.log(ctx).Errorf(err.Error())
+		run.Error = "countries were not read"
+		return run, err
 	}
+	run.Total = len(countries)
+	report := &models.LoadReport{Succeeded: []string{}, Failed: []models.CountryReason{}, Skipped: []models.CountryReason{}}
+	run.Report = report
+	update()
 	var changedCountries []models.Country
-	for _, country := range countries {
-		request := fmt.Sprintf("https://en.wikipedia.org/w/api.php?action=query&prop=pageimages&format=json&formatversion=2&piprop=original&titles=%s", country.EnglishName)
-		response, err := http.Get(request)
-		if err != nil {
-			c.logger.Errorf("Error while sending request to wikipedia:%s", err)
+	c.fetcher.run(ctx, countries, func(result fetchResult) {
+		run.Checked++
+		alpha2 := result.country.Alpha2
+		switch {
+		case result.err == nil:
+			result.country.Url = result.url
+			changedCountries = append(changedCountries, result.country)
+			report.Succeeded = append(report.Succeeded, alpha2)
+		case errors.Is(result.err, ErrNoFlag):
+			report.Skipped = append(report.Skipped, models.CountryReason{Alpha2: alpha2, Reason: result.err.Error()})
+		case ctx.Err() != nil:
+			report.Skipped = append(report.Skipped, models.CountryReason{Alpha2: alpha2, Reason: "loading was cancelled"})
+		default:
+			c.log(ctx).Errorf("Error while looking for flag of %s:%s", alpha2, result.err)
+			report.Failed = append(report.Failed, models.CountryReason{Alpha2: alpha2, Reason: result.err.Error()})
 		}
-		defer response.Body.Close()
-		b, err := io.ReadAll(response.Body)
+		run.Found, run.Failed, run.Skipped = len(report.Succeeded), len(report.Failed), len(report.Skipped)
+		update()
+	})
+	c.log(ctx).Infof("Flags of %d countries found, %d failed, %d skipped", run.Found, run.Failed, run.Skipped)
+	if len(changedCountries) != 0 {
+		err = c.repository.LoadImages(ctx, changedCountries)
 		if err != nil {
-			c.logger.Errorf("Error while sending request to wikipedia:%s", err)
-		}
-		url := gjson.Get(string(b), "query.pages.0.original.source")
-		if url.String() != "" {
-			country.Url = url.String()
-			changedCountries = append(changedCountries, country)
+			c.log(ctx).Errorf("Error while saving images url:%s", err)
+			run.Error = "images were not saved"
+			return run, err
 		}
 	}
-	err = c.repository.LoadImages(changedCountries)
+	c.downloadImages(ctx, &run, update)
+	if run.Failed > 0 {
+		run.Error = fmt.Sprintf("flags of %d countries were not loaded", run.Failed)
+	}
+	return run, nil
+}
+
+// downloadImages downloads the images of all the countries with the url, the unchanged ones are not saved again.
+// The countries whose images were not downloaded are added to the failed ones of the run.
+func (c *CountryService) downloadImages(ctx context.Context, run *models.LoadRun, update func()) {
+	countries, _, err := c.repository.GetCountries(ctx, &models.Filters{})
 	if err != nil {
-		c.logger.Errorf("Error while saving images url:%s", err)
+		c.log(ctx).Errorf("Error while reading countries for downloading images:%s", err)
+		return
+	}
+	var downloads []models.Country
+	for _, country := range countries {
+		if country.Url != "" {
+			downloads = append(downloads, country)
+		}
+	}
+	c.fetcher.each(ctx, downloads, c.downloader.download, func(result fetchResult) {
+		switch {
+		case result.err == nil:
+			if result.url != "" {
+				run.Downloaded++
+			}
+		case ctx.Err() != nil:
+		default:
+			alpha2 := result.country.Alpha2
+			c.log(ctx).Errorf("Error while downloading flag of %s:%s", alpha2, result.err)
+			run.Report.Failed = append(run.Report.Failed, models.CountryReason{Alpha2: alpha2,
+				Reason: "image was not downloaded: " + result.err.Error()})
+			run.Failed = len(run.Report.Failed)
+		}
+		update()
+	})
+	c.log(ctx).Infof("Images of %d flags downloaded, %d are not changed", run.Downloaded, len(downloads)-run.Downloaded)
+}
+
+// snapshot copies the run together with its report, so that the loader can go on changing its own.
+func snapshot(run models.LoadRun) models.LoadRun {
+	if run.Report != nil {
+		report := models.LoadReport{
+			Succeeded: append([]string{}, run.Report.Succeeded...),
+			Failed:    append([]models.CountryReason{}, run.Report.Failed...),
+			Skipped:   append([]models.CountryReason{}, run.Report.Skipped...),
+		}
+		run.Report = &report
 	}
+	return run
 }
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/time/rate"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"tranee_service/models"
+)
+
+// FetchConfig configures the fetching of the flags.
+type FetchConfig struct {
+	// Workers is the number of countries looked for at once.
+	Workers int
+	// Rate is the number of requests per second, Burst is how many of them can be sent at once.
+	Rate  float64
+	Burst int
+	// Timeout limits one request.
+	Timeout time.Duration
+	// Retries is how many times a request that failed with 429, 5xx or a network error is repeated.
+	// The pause before the retry starts at BaseBackoff and doubles up to MaxBackoff.
+	Retries     int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultFetchConfig() FetchConfig {
+	return FetchConfig{
+		Workers:     4,
+		Rate:        5,
+		Burst:       5,
+		Timeout:     10 * time.Second,
+		Retries:     3,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+	}
+}
+
+// statusError is the unexpected status of the response of the source.
+type statusError struct {
+	status     int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.status)
+}
+
+func newStatusError(response *http.Response) *statusError {
+	err := &statusError{status: response.StatusCode}
+	if seconds, parseErr := strconv.Atoi(response.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		err.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return err
+}
+
+// flagFetcher looks for the flags of many countries at once, keeping to the rate limit of the source.
+type flagFetcher struct {
+	config   FetchConfig
+	limiter  *rate.Limiter
+	provider FlagProvider
+}
+
+func newFlagFetcher(config FetchConfig, provider FlagProvider) *flagFetcher {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	limit := rate.Limit(config.Rate)
+	if config.Rate <= 0 {
+		limit = rate.Inf
+	}
+	return &flagFetcher{config: config, limiter: rate.NewLimiter(limit, config.Burst), provider: provider}
+}
+
+// fetchFunc does the request about one country, like looking for its flag or downloading it.
+type fetchFunc func(ctx context.Context, country models.Country) (string, error)
+
+// fetchResult is the result of the looking for the flag of one country.
+type fetchResult struct {
+	country models.Country
+	url     string
+	err     error
+}
+
+// run looks for the flags of the countries. The countries that are not looked for because ctx is done are
+// returned with the error of ctx. done is called with every result as soon as it is known, one call at a time.
+func (f *flagFetcher) run(ctx context.Context, countries []models.Country, done func(result fetchResult)) []fetchResult {
+	return f.each(ctx, countries, f.provider.Flag, done)
+}
+
+// each does fetch for every country the way run looks for the flags.
+func (f *flagFetcher) each(ctx context.Context, countries []models.Country, fetch fetchFunc,
+	done func(result fetchResult)) []fetchResult {
+	results := make([]fetchResult, len(countries))
+	indexes := make(chan int)
+	var mu sync.Mutex
+	finish := func(index int, result fetchResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[index] = result
+		if done != nil {
+			done(result)
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < f.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				url, err := f.retry(ctx, countries[index], fetch)
+				finish(index, fetchResult{country: countries[index], url: url, err: err})
+			}
+		}()
+	}
+	for i := range countries {
+		if ctx.Err() != nil {
+			finish(i, fetchResult{country: countries[i], err: ctx.Err()})
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// retry does fetch for the country, repeating it while the error is retryable and the retries are left.
+func (f *flagFetcher) retry(ctx context.Context, country models.Country, fetch fetchFunc) (string, error) {
+	for attempt := 0; ; attempt++ {
+		if err := f.limiter.Wait(ctx); err != nil {
+			return "", err
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, f.config.Timeout)
+		url, err := fetch(attemptCtx, country)
+		cancel()
+		if err == nil || attempt >= f.config.Retries || !retryable(ctx, err) {
+			return url, err
+		}
+		select {
+		case <-time.After(f.backoff(attempt, err)):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether the error can go away by itself: the source is overloaded or the request timed out.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNoFlag) {
+		return false
+	}
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.status == http.StatusTooManyRequests || status.status >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// backoff returns the pause before the retry. The Retry-After of the source is kept to, but not longer than
+// MaxBackoff, so that one source can not stall the loading.
+func (f *flagFetcher) backoff(attempt int, err error) time.Duration {
+	var status *statusError
+	if errors.As(err, &status) && status.retryAfter > 0 {
+		if status.retryAfter > f.config.MaxBackoff {
+			return f.config.MaxBackoff
+		}
+		return status.retryAfter
+	}
+	backoff := f.config.BaseBackoff << attempt
+	if backoff > f.config.MaxBackoff || backoff <= 0 {
+		backoff = f.config.MaxBackoff
+	}
+	return backoff
+}
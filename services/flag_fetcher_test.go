@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"tranee_service/models"
+)
+
+// providerFunc is a FlagProvider made of a function.
+type providerFunc func(ctx context.Context, country models.Country) (string, error)
+
+func (f providerFunc) Flag(ctx context.Context, country models.Country) (string, error) {
+	return f(ctx, country)
+}
+
+func testFetchConfig() FetchConfig {
+	return FetchConfig{
+		Workers:     2,
+		Timeout:     time.Second,
+		Retries:     2,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  10 * time.Millisecond,
+	}
+}
+
+func TestFetcherRetry(t *testing.T) {
+	testTable := []struct {
+		name             string
+		errors           []error
+		expectedURL      string
+		expectedError    error
+		expectedAttempts int
+	}{
+		{
+			name:             "OK",
+			expectedURL:      "flag.svg",
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Retry after 429 and 503",
+			errors:           []error{&statusError{status: http.StatusTooManyRequests}, &statusError{status: http.StatusServiceUnavailable}},
+			expectedURL:      "flag.svg",
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Retry after timeout",
+			errors:           []error{context.DeadlineExceeded},
+			expectedURL:      "flag.svg",
+			expectedAttempts: 2,
+		},
+		{
+			name: "Retries are over",
+			errors: []error{&statusError{status: http.StatusBadGateway}, &statusError{status: http.StatusBadGateway},
+				&statusError{status: http.StatusBadGateway}},
+			expectedError:    &statusError{status: http.StatusBadGateway},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "No retry after 404",
+			errors:           []error{&statusError{status: http.StatusNotFound}},
+			expectedError:    &statusError{status: http.StatusNotFound},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "No retry without flag",
+			errors:           []error{ErrNoFlag},
+			expectedError:    ErrNoFlag,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			attempts := 0
+			fetcher := newFlagFetcher(testFetchConfig(), nil)
+			fetch := func(ctx context.Context, country models.Country) (string, error) {
+				attempts++
+				if attempts <= len(testCase.errors) {
+					return "", testCase.errors[attempts-1]
+				}
+				return "flag.svg", nil
+			}
+
+			url, err := fetcher.retry(context.Background(), models.Country{Alpha2: "RU"}, fetch)
+
+			assert.Equal(t, testCase.expectedURL, url)
+			assert.Equal(t, testCase.expectedError, err)
+			assert.Equal(t, testCase.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestFetcherRun(t *testing.T) {
+	countries := []models.Country{{Alpha2: "RU"}, {Alpha2: "BY"}, {Alpha2: "KZ"}, {Alpha2: "AM"}, {Alpha2: "GE"}}
+	var running, maxRunning int32
+	var mu sync.Mutex
+	fetcher := newFlagFetcher(testFetchConfig(), providerFunc(func(ctx context.Context, country models.Country) (string, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		if current > maxRunning {
+			maxRunning = current
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		if country.Alpha2 == "KZ" {
+			return "", ErrNoFlag
+		}
+		return country.Alpha2 + ".svg", nil
+	}))
+
+	var done []string
+	results := fetcher.run(context.Background(), countries, func(result fetchResult) {
+		done = append(done, result.country.Alpha2)
+	})
+
+	assert.LessOrEqual(t, maxRunning, int32(2))
+	assert.ElementsMatch(t, []string{"RU", "BY", "KZ", "AM", "GE"}, done)
+	for i, result := range results {
+		assert.Equal(t, countries[i], result.country)
+		if result.country.Alpha2 == "KZ" {
+			assert.True(t, errors.Is(result.err, ErrNoFlag))
+		} else {
+			assert.NoError(t, result.err)
+			assert.Equal(t, result.country.Alpha2+".svg", result.url)
+		}
+	}
+}
+
+func TestFetcherRateLimit(t *testing.T) {
+	config := testFetchConfig()
+	config.Rate = 100
+	config.Burst = 1
+	fetcher := newFlagFetcher(config, providerFunc(func(ctx context.Context, country models.Country) (string, error) {
+		return "flag.svg", nil
+	}))
+	start := time.Now()
+
+	fetcher.run(context.Background(), make([]models.Country, 6), nil)
+
+	assert.GreaterOrEqual(t, time.Since(start), 45*time.Millisecond)
+}
+
+func TestFetcherCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fetcher := newFlagFetcher(testFetchConfig(), providerFunc(func(ctx context.Context, country models.Country) (string, error) {
+		return "flag.svg", nil
+	}))
+
+	results := fetcher.run(ctx, []models.Country{{Alpha2: "RU"}, {Alpha2: "BY"}}, nil)
+
+	for _, result := range results {
+		assert.Equal(t, context.Canceled, result.err)
+	}
+}
+
+func TestFetcherBackoff(t *testing.T) {
+	config := FetchConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	fetcher := newFlagFetcher(config, nil)
+
+	testTable := []struct {
+		name            string
+		attempt         int
+		err             error
+		expectedBackoff time.Duration
+	}{
+		{
+			name:            "Exponential",
+			attempt:         2,
+			err:             &statusError{status: http.StatusServiceUnavailable},
+			expectedBackoff: 4 * time.Second,
+		},
+		{
+			name:            "Exponential is capped",
+			attempt:         5,
+			err:             context.DeadlineExceeded,
+			expectedBackoff: 10 * time.Second,
+		},
+		{
+			name:            "Retry-After",
+			attempt:         0,
+			err:             &statusError{status: http.StatusTooManyRequests, retryAfter: 3 * time.Second},
+			expectedBackoff: 3 * time.Second,
+		},
+		{
+			name:            "Retry-After is capped",
+			attempt:         0,
+			err:             &statusError{status: http.StatusTooManyRequests, retryAfter: time.Minute},
+			expectedBackoff: 10 * time.Second,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expectedBackoff, fetcher.backoff(testCase.attempt, testCase.err))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"path/filepath"
+	"syscall"
+	"time"
+	"tranee_service/internal/blobstore"
+	"tranee_service/models"
+)
+
+// maxFlagSize limits the downloaded images.
+const maxFlagSize = 20 << 20
+
+// flagKey is the key of the image of the flag of the country in the store.
+func flagKey(alpha2 string) string {
+	return "flags/" + alpha2
+}
+
+// newImageClient returns the client the images are downloaded with. It also reads the file:// urls
+// the local providers return, but only the files under dirs. The urls are set by the admins, so the client
+// does not connect to the loopback and private addresses, and goes without a proxy for the check to see
+// the real address.
+func newImageClient(dirs []string) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: publicAddress}
+	transport.DialContext = dialer.DialContext
+	transport.RegisterProtocol("file", fileTransport(dirs))
+	return &http.Client{Transport: transport}
+}
+
+// publicAddress refuses the connections to the addresses of the host and of the internal networks.
+func publicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("address %s is not public", host)
+	}
+	return nil
+}
+
+// fileTransport serves the file:// urls of the files under its directories.
+type fileTransport []string
+
+func (t fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Clean(filepath.FromSlash(req.URL.Path))
+	for _, dir := range t {
+		relative, inside := relativePath(dir, path)
+		if !inside {
+			continue
+		}
+		file := req.Clone(req.Context())
+		file.URL.Path = "/" + filepath.ToSlash(relative)
+		return http.NewFileTransport(http.Dir(dir)).RoundTrip(file)
+	}
+	return nil, fmt.Errorf("file %s is not in the flag directories", path)
+}
+
+// flagDownloader keeps the images of the flags in the store, so that the clients do not depend on the source.
+type flagDownloader struct {
+	client *http.Client
+	store  blobstore.Store
+}
+
+// download saves the image the url of the country points at and returns its hash, or "" when the saved image
+// is the same. The request carries the ETag of the saved image, so an unchanged image is not sent again
+// by the sources that support it.
+func (d *flagDownloader) download(ctx context.Context, country models.Country) (string, error) {
+	key := flagKey(country.Alpha2)
+	saved, err := d.store.Stat(ctx, key)
+	if err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, country.Url, nil)
+	if err != nil {
+		return "", err
+	}
+	if saved.Source == country.Url && saved.SourceETag != "" {
+		req.Header.Set("If-None-Match", saved.SourceETag)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError(resp)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxFlagSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(content) > maxFlagSize {
+		return "", fmt.Errorf("image is larger than %d bytes", maxFlagSize)
+	}
+	contentType, err := imageType(resp.Header.Get("Content-Type"), content)
+	if err != nil {
+		return "", err
+	}
+	etag := resp.Header.Get("ETag")
+	if saved.Hash == blobstore.Hash(content) && saved.ContentType == contentType && saved.Source == country.Url &&
+		saved.SourceETag == etag {
+		return "", nil
+	}
+	info, err := d.store.Put(ctx, key, content, blobstore.Info{ContentType: contentType, Source: country.Url, SourceETag: etag})
+	if err != nil {
+		return "", err
+	}
+	return info.Hash, nil
+}
+
+// imageType returns the media type of the image. The type sent by the source is checked against the content,
+// since the sources often send images as application/octet-stream or text/plain.
+func imageType(header string, content []byte) (string, error) {
+	declared, _, _ := mime.ParseMediaType(header)
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	switch {
+	case (detected == "text/xml" || detected == "text/plain") && isSVG(content):
+		return "image/svg+xml", nil
+	case len(detected) > 6 && detected[:6] == "image/":
+		return detected, nil
+	case len(declared) > 6 && declared[:6] == "image/" && detected == "application/octet-stream":
+		return declared, nil
+	}
+	return "", fmt.Errorf("content of type %q is not an image", detected)
+}
+
+// isSVG reports whether the XML or plain text content is an SVG document, DetectContentType does not know them.
+func isSVG(content []byte) bool {
+	head := content
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return bytes.Contains(head, []byte("<svg"))
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/blobstore"
+	"tranee_service/models"
+)
+
+const testSVG = `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`
+
+func TestDownload(t *testing.T) {
+	content, etag := testSVG, `"v1"`
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if etag != "" && req.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	store, err := blobstore.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloader := &flagDownloader{client: server.Client(), store: store}
+	country := models.Country{Alpha2: "RU", Url: server.URL + "/ru.svg"}
+	ctx := context.Background()
+
+	hash, err := downloader.download(ctx, country)
+	assert.NoError(t, err)
+	assert.Equal(t, blobstore.Hash([]byte(testSVG)), hash)
+	info, err := store.Stat(ctx, flagKey("RU"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", info.ContentType)
+
+	hash, err = downloader.download(ctx, country)
+	assert.NoError(t, err)
+	assert.Equal(t, "", hash)
+	assert.Equal(t, 1, notModified)
+
+	etag = ""
+	hash, err = downloader.download(ctx, country)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", hash, "the ETag of the source has changed")
+	hash, err = downloader.download(ctx, country)
+	assert.NoError(t, err)
+	assert.Equal(t, "", hash, "the content is the same")
+
+	content = `<svg xmlns="http://www.w3.org/2000/svg" width="2"/>`
+	hash, err = downloader.download(ctx, country)
+	assert.NoError(t, err)
+	assert.Equal(t, blobstore.Hash([]byte(content)), hash)
+	assert.Equal(t, 5, requests)
+}
+
+func TestDownloadErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/missing.svg":
+			w.WriteHeader(http.StatusNotFound)
+		case "/page.html":
+			w.Write([]byte("<html><body><svg/></body></html>"))
+		}
+	}))
+	defer server.Close()
+	store, err := blobstore.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloader := &flagDownloader{client: server.Client(), store: store}
+
+	_, err = downloader.download(context.Background(), models.Country{Alpha2: "RU", Url: server.URL + "/missing.svg"})
+	assert.Equal(t, &statusError{status: http.StatusNotFound}, err)
+	_, err = downloader.download(context.Background(), models.Country{Alpha2: "RU", Url: server.URL + "/page.html"})
+	assert.EqualError(t, err, `content of type "text/html" is not an image`)
+	_, err = store.Stat(context.Background(), flagKey("RU"))
+	assert.Equal(t, blobstore.ErrNotFound, err)
+}
+
+func TestDownloadFile(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(filepath.Join(dir, "BY.png"), png, 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := blobstore.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloader := &flagDownloader{client: newImageClient([]string{dir}), store: store}
+	url, err := fileURL(filepath.Join(dir, "BY.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := downloader.download(context.Background(), models.Country{Alpha2: "BY", Url: url})
+
+	assert.NoError(t, err)
+	assert.Equal(t, blobstore.Hash(png), hash)
+	info, err := store.Stat(context.Background(), flagKey("BY"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", info.ContentType)
+
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "RU.png"), png, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(outside, "RU.png"), filepath.Join(dir, "..", filepath.Base(outside), "RU.png")} {
+		url, err := fileURL(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = downloader.download(context.Background(), models.Country{Alpha2: "RU", Url: url})
+		assert.Error(t, err)
+	}
+	_, err = store.Stat(context.Background(), flagKey("RU"))
+	assert.Equal(t, blobstore.ErrNotFound, err)
+}
+
+func TestCheckUrl(t *testing.T) {
+	dir := t.TempDir()
+	countries := &CountryService{localDirs: []string{dir}}
+	inside, err := fileURL(filepath.Join(dir, "BY.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testTable := []struct {
+		name          string
+		url           string
+		expectedError bool
+	}{
+		{
+			name: "Empty",
+			url:  "",
+		},
+		{
+			name: "Http",
+			url:  "https://example.com/by.png",
+		},
+		{
+			name: "File of a provider",
+			url:  inside,
+		},
+		{
+			name:          "File outside of the providers",
+			url:           "file:///etc/passwd",
+			expectedError: true,
+		},
+		{
+			name:          "File that leaves the directory",
+			url:           inside + "/../../passwd",
+			expectedError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := countries.checkUrl(testCase.url)
+
+			if testCase.expectedError {
+				assert.Equal(t, &MyErrors.ValidationError{FlagFile: true}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	for address, expectedPublic := range map[string]bool{
+		"93.184.216.34:443":  true,
+		"[2606:4700::1]:443": true,
+		"127.0.0.1:80":       false,
+		"[::1]:80":           false,
+		"10.1.2.3:80":        false,
+		"192.168.0.10:80":    false,
+		"169.254.169.254:80": false,
+		"0.0.0.0:80":         false,
+	} {
+		err := publicAddress("tcp", address, nil)
+		assert.Equal(t, expectedPublic, err == nil, address)
+	}
+}
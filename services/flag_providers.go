@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"tranee_service/models"
+)
+
+// ErrNoFlag is returned by a FlagProvider that has no flag of the country.
+var ErrNoFlag = errors.New("no flag found")
+
+// FlagProvider finds the url of the image of the flag of the country.
+type FlagProvider interface {
+	Flag(ctx context.Context, country models.Country) (string, error)
+}
+
+// DefaultWikipediaURL is the address of the API of the English Wikipedia.
+const DefaultWikipediaURL = "https://en.wikipedia.org/w/api.php"
+
+// WikipediaProvider takes the original image of the Wikipedia page named by the English name of the country.
+type WikipediaProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewWikipediaProvider(baseURL string, client *http.Client) *WikipediaProvider {
+	return &WikipediaProvider{baseURL: baseURL, client: client}
+}
+
+func (w *WikipediaProvider) Flag(ctx context.Context, country models.Country) (string, error) {
+	query := url.Values{
+		"action":        {"query"},
+		"prop":          {"pageimages"},
+		"format":        {"json"},
+		"formatversion": {"2"},
+		"piprop":        {"original"},
+		"titles":        {country.EnglishName},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, w.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error while creating request to wikipedia:%w", err)
+	}
+	response, err := w.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("error while sending request to wikipedia:%w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", newStatusError(response)
+	}
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("error while reading response of wikipedia:%w", err)
+	}
+	source := gjson.GetBytes(b, "query.pages.0.original.source").String()
+	if source == "" {
+		return "", ErrNoFlag
+	}
+	return source, nil
+}
+
+// localProvider is a FlagProvider that returns the file:// urls of the files under its directories.
+// Only these directories are read by the downloader.
+type localProvider interface {
+	localDirs() []string
+}
+
+// localDirs returns the directories of the local providers among the provider.
+func localDirs(provider FlagProvider) []string {
+	if local, ok := provider.(localProvider); ok {
+		return local.localDirs()
+	}
+	return nil
+}
+
+// flagExtensions are the image files the DirectoryProvider looks for.
+var flagExtensions = []string{".svg", ".png", ".jpg", ".jpeg"}
+
+// DirectoryProvider takes the flags from the files of the directory named by alpha_2, like "RU.svg" or "ru.png".
+type DirectoryProvider struct {
+	dir string
+}
+
+func NewDirectoryProvider(dir string) *DirectoryProvider {
+	return &DirectoryProvider{dir: dir}
+}
+
+func (d *DirectoryProvider) Flag(ctx context.Context, country models.Country) (string, error) {
+	if country.Alpha2 == "" {
+		return "", ErrNoFlag
+	}
+	for _, name := range []string{strings.ToUpper(country.Alpha2), strings.ToLower(country.Alpha2)} {
+		for _, extension := range flagExtensions {
+			path := filepath.Join(d.dir, name+extension)
+			if _, err := os.Stat(path); err == nil {
+				return fileURL(path)
+			}
+		}
+	}
+	return "", ErrNoFlag
+}
+
+func (d *DirectoryProvider) localDirs() []string {
+	dir, err := filepath.Abs(d.dir)
+	if err != nil {
+		return nil
+	}
+	return []string{dir}
+}
+
+// ManifestProvider takes the flags from a JSON object that maps alpha_2 to the url of the flag. A relative path
+// is taken from the directory of the manifest, the files outside of it are not accepted.
+type ManifestProvider struct {
+	dir   string
+	flags map[string]string
+}
+
+func LoadManifestProvider(path string) (*ManifestProvider, error) {
+	var manifest map[string]string
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loadManifestProvider: error while reading manifest:%w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("loadManifestProvider: error while decoding manifest:%w", err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("loadManifestProvider: invalid path %q:%w", path, err)
+	}
+	flags := make(map[string]string, len(manifest))
+	for alpha2, location := range manifest {
+		parsed, err := url.Parse(location)
+		switch {
+		case err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https"):
+		case err == nil && parsed.Scheme != "":
+			return nil, fmt.Errorf("loadManifestProvider: flag of %s is neither a path nor an http url", alpha2)
+		default:
+			if !filepath.IsAbs(location) {
+				location = filepath.Join(dir, location)
+			}
+			if _, inside := relativePath(dir, location); !inside {
+				return nil, fmt.Errorf("loadManifestProvider: flag of %s is outside of %s", alpha2, dir)
+			}
+			if location, err = fileURL(location); err != nil {
+				return nil, err
+			}
+		}
+		flags[strings.ToUpper(alpha2)] = location
+	}
+	return &ManifestProvider{dir: dir, flags: flags}, nil
+}
+
+func (m *ManifestProvider) Flag(ctx context.Context, country models.Country) (string, error) {
+	if location, ok := m.flags[strings.ToUpper(country.Alpha2)]; ok {
+		return location, nil
+	}
+	return "", ErrNoFlag
+}
+
+func (m *ManifestProvider) localDirs() []string {
+	return []string{m.dir}
+}
+
+// relativePath returns the path relative to dir, the bool is false when the path is outside of dir.
+func relativePath(dir, path string) (string, bool) {
+	relative, err := filepath.Rel(dir, path)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relative, true
+}
+
+func fileURL(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("fileURL: invalid path %q:%w", path, err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}).String(), nil
+}
+
+// FallbackProviders asks the providers in order and returns the first flag found. If none of them has the flag,
+// the last error other than ErrNoFlag is returned, so that a failed source can be retried.
+type FallbackProviders []FlagProvider
+
+func (f FallbackProviders) Flag(ctx context.Context, country models.Country) (string, error) {
+	result := ErrNoFlag
+	for _, provider := range f {
+		location, err := provider.Flag(ctx, country)
+		if err == nil {
+			return location, nil
+		}
+		if !errors.Is(err, ErrNoFlag) {
+			result = err
+		}
+	}
+	return "", result
+}
+
+func (f FallbackProviders) localDirs() []string {
+	var dirs []string
+	for _, provider := range f {
+		dirs = append(dirs, localDirs(provider)...)
+	}
+	return dirs
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"tranee_service/models"
+)
+
+func TestWikipediaProvider(t *testing.T) {
+	testTable := []struct {
+		name          string
+		status        int
+		body          string
+		expectedURL   string
+		expectedError error
+	}{
+		{
+			name:        "OK",
+			status:      200,
+			body:        `{"query":{"pages":[{"title":"Armenia","original":{"source":"https://upload.wikimedia.org/am.svg"}}]}}`,
+			expectedURL: "https://upload.wikimedia.org/am.svg",
+		},
+		{
+			name:          "No image",
+			status:        200,
+			body:          `{"query":{"pages":[{"title":"Armenia"}]}}`,
+			expectedError: ErrNoFlag,
+		},
+		{
+			name:          "Too many requests",
+			status:        429,
+			expectedError: &statusError{status: 429},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				query = req.URL.Query().Get("titles")
+				w.WriteHeader(testCase.status)
+				w.Write([]byte(testCase.body))
+			}))
+			defer server.Close()
+			provider := NewWikipediaProvider(server.URL, server.Client())
+
+			url, err := provider.Flag(context.Background(), models.Country{EnglishName: "Republic of Armenia"})
+
+			assert.Equal(t, "Republic of Armenia", query)
+			assert.Equal(t, testCase.expectedURL, url)
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
+
+func TestLocalProviders(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"am.svg", "BY.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("image"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest := filepath.Join(dir, "flags.json")
+	content := `{"ru": "https://example.com/ru.svg", "BY": "BY.png"}`
+	if err := os.WriteFile(manifest, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	manifestProvider, err := LoadManifestProvider(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	directoryProvider := NewDirectoryProvider(dir)
+
+	testTable := []struct {
+		name          string
+		provider      FlagProvider
+		alpha2        string
+		expectedURL   string
+		expectedError error
+	}{
+		{
+			name:        "Directory",
+			provider:    directoryProvider,
+			alpha2:      "AM",
+			expectedURL: "file://" + filepath.ToSlash(filepath.Join(dir, "am.svg")),
+		},
+		{
+			name:          "Directory without flag",
+			provider:      directoryProvider,
+			alpha2:        "RU",
+			expectedError: ErrNoFlag,
+		},
+		{
+			name:        "Manifest with url",
+			provider:    manifestProvider,
+			alpha2:      "RU",
+			expectedURL: "https://example.com/ru.svg",
+		},
+		{
+			name:        "Manifest with relative path",
+			provider:    manifestProvider,
+			alpha2:      "BY",
+			expectedURL: "file://" + filepath.ToSlash(filepath.Join(dir, "BY.png")),
+		},
+		{
+			name:          "Manifest without flag",
+			provider:      manifestProvider,
+			alpha2:        "AM",
+			expectedError: ErrNoFlag,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			url, err := testCase.provider.Flag(context.Background(), models.Country{Alpha2: testCase.alpha2})
+
+			assert.Equal(t, testCase.expectedURL, url)
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
+
+func TestManifestOutsideDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, location := range []string{"../flags/BY.png", "/etc/passwd", "file:///etc/passwd"} {
+		t.Run(location, func(t *testing.T) {
+			manifest := filepath.Join(dir, "flags.json")
+			content := fmt.Sprintf(`{"BY": %q}`, location)
+			if err := os.WriteFile(manifest, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadManifestProvider(manifest)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProviderDirectories(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest", "flags.json")
+	if err := os.MkdirAll(filepath.Dir(manifest), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifest, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	manifestProvider, err := LoadManifestProvider(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := FallbackProviders{NewWikipediaProvider(DefaultWikipediaURL, nil), manifestProvider, NewDirectoryProvider(dir)}
+
+	assert.Equal(t, []string{filepath.Dir(manifest), dir}, localDirs(provider))
+}
+
+func TestFallbackProviders(t *testing.T) {
+	failed := errors.New("source is not available")
+	noFlag := providerFunc(func(ctx context.Context, country models.Country) (string, error) {
+		return "", ErrNoFlag
+	})
+	failing := providerFunc(func(ctx context.Context, country models.Country) (string, error) {
+		return "", failed
+	})
+	found := providerFunc(func(ctx context.Context, country models.Country) (string, error) {
+		return "flag.svg", nil
+	})
+
+	testTable := []struct {
+		name          string
+		providers     FallbackProviders
+		expectedURL   string
+		expectedError error
+	}{
+		{
+			name:        "First has no flag",
+			providers:   FallbackProviders{noFlag, found},
+			expectedURL: "flag.svg",
+		},
+		{
+			name:        "First failed",
+			providers:   FallbackProviders{failing, found},
+			expectedURL: "flag.svg",
+		},
+		{
+			name:          "None has flag",
+			providers:     FallbackProviders{noFlag, noFlag},
+			expectedError: ErrNoFlag,
+		},
+		{
+			name:          "Failure is reported",
+			providers:     FallbackProviders{failing, noFlag},
+			expectedError: failed,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			url, err := testCase.providers.Flag(context.Background(), models.Country{Alpha2: "RU"})
+
+			assert.Equal(t, testCase.expectedURL, url)
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
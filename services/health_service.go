@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+	"tranee_service/repositories"
+)
+
+// pingTimeout limits the time the readiness check waits for the data base.
+const pingTimeout = 2 * time.Second
+
+type HealthService struct {
+	repository *repositories.Repository
+	logger     logging.Logger
+	state      *State
+}
+
+func NewHealthService(repository *repositories.Repository, logger logging.Logger, state *State) *HealthService {
+	return &HealthService{repository: repository, logger: logger, state: state}
+}
+
+func (h *HealthService) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, h.logger)
+}
+
+// Live reports that the process is up. It does not check the dependencies, a restart would not fix them.
+func (h *HealthService) Live(ctx context.Context) models.Health {
+	return models.Health{Status: models.StatusPass}
+}
+
+// Ready reports whether the service can serve requests. It fails if any check fails, warnings are only reported.
+func (h *HealthService) Ready(ctx context.Context) models.Health {
+	health := models.Health{
+		Status: models.StatusPass,
+		Checks: map[string]models.Check{
+			"database":    h.checkDatabase(ctx),
+			"migrations":  h.checkMigrations(ctx),
+			"seed":        h.state.seedCheck(),
+			"flag_loader": h.state.loaderCheck(),
+		},
+	}
+	for _, check := range health.Checks {
+		if check.Status == models.StatusFail {
+			health.Status = models.StatusFail
+		}
+	}
+	return health
+}
+
+func (h *HealthService) checkDatabase(ctx context.Context) models.Check {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if err := h.repository.Ping(ctx); err != nil {
+		h.log(ctx).Errorf("Readiness check failed:%s", err)
+		return models.Check{Status: models.StatusFail, Detail: "data base is not reachable"}
+	}
+	return models.Check{Status: models.StatusPass}
+}
+
+func (h *HealthService) checkMigrations(ctx context.Context) models.Check {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	version, dirty, err := h.repository.MigrationVersion(ctx)
+	if err != nil {
+		h.log(ctx).Errorf("Readiness check failed:%s", err)
+		return models.Check{Status: models.StatusFail, Detail: "migration version is unknown"}
+	}
+	check := models.Check{Status: models.StatusPass, Version: &version}
+	switch {
+	case dirty:
+		check.Status = models.StatusFail
+		check.Detail = "last migration failed"
+	case version < repositories.SchemaVersion:
+		check.Status = models.StatusFail
+		check.Detail = fmt.Sprintf("schema version %d is required", repositories.SchemaVersion)
+	}
+	return check
+}
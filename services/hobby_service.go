@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 	"tranee_service/repositories"
@@ -15,10 +16,26 @@ func NewHobbyService(repository *repositories.Repository, logger logging.Logger)
 	return &HobbyService{repository: repository, logger: logger}
 }
 
-func (h *HobbyService) CreateHobby(hobby *models.Hobby) (int, error) {
-	return h.repository.AppHobbies.CreateHobby(hobby)
+func (h *HobbyService) CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error) {
+	return h.repository.AppHobbies.CreateHobby(ctx, hobby)
 }
 
-func (h *HobbyService) GetHobbies() ([]models.ResponseHobby, error) {
-	return h.repository.AppHobbies.GetHobbies()
+func (h *HobbyService) GetHobbies(ctx context.Context) ([]models.ResponseHobby, error) {
+	return h.repository.AppHobbies.GetHobbies(ctx)
+}
+
+func (h *HobbyService) GetHobbyById(ctx context.Context, hobbyId int) (*models.ResponseHobby, error) {
+	return h.repository.AppHobbies.GetHobbyById(ctx, hobbyId)
+}
+
+func (h *HobbyService) ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error {
+	return h.repository.AppHobbies.ChangeHobby(ctx, hobby, hobbyId)
+}
+
+func (h *HobbyService) DeleteHobby(ctx context.Context, hobbyId int) error {
+	return h.repository.AppHobbies.DeleteHobby(ctx, hobbyId)
+}
+
+func (h *HobbyService) GetUsersByHobbyId(ctx context.Context, hobbyId int) ([]models.ResponseUser, error) {
+	return h.repository.AppHobbies.GetUsersByHobbyId(ctx, hobbyId)
 }
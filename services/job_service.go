@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+)
+
+// jobHistory is the number of finished jobs that are kept.
+const jobHistory = 20
+
+// JobService runs the loading of the flags in background. Only one loading runs at a time, a new request while
+// it runs gets the running job.
+type JobService struct {
+	countries AppCountries
+	logger    logging.Logger
+	jobs      *background
+
+	mu       sync.Mutex
+	running  *models.Job
+	finished []models.Job
+}
+
+func NewJobService(countries AppCountries, logger logging.Logger) *JobService {
+	return &JobService{countries: countries, logger: logger, jobs: newBackground()}
+}
+
+func (j *JobService) log(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx, j.logger)
+}
+
+// StartLoadImages starts the loading of the flags. The bool is false when the loading was already running, then
+// the running job is returned.
+func (j *JobService) StartLoadImages(ctx context.Context) (*models.Job, bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running != nil {
+		job := *j.running
+		return &job, false, nil
+	}
+	id, err := newJobId()
+	if err != nil {
+		return nil, false, fmt.Errorf("startLoadImages: error while generating job id:%w", err)
+	}
+	j.running = &models.Job{Id: id, Type: models.JobLoadImages, Status: models.JobRunning}
+	job := *j.running
+	err = j.jobs.run(func(ctx context.Context) {
+		run, err := j.countries.LoadImages(ctx, func(run models.LoadRun) {
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			j.running.LoadRun = run
+		})
+		j.finish(ctx, run, err)
+	})
+	if err != nil {
+		j.running = nil
+		return nil, false, fmt.Errorf("startLoadImages:%w", err)
+	}
+	j.log(ctx).Infof("Job %s started", id)
+	return &job, true, nil
+}
+
+func (j *JobService) finish(ctx context.Context, run models.LoadRun, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job := *j.running
+	job.LoadRun = run
+	switch {
+	case ctx.Err() != nil:
+		job.Status = models.JobCancelled
+	case err != nil:
+		job.Status = models.JobFailed
+	default:
+		job.Status = models.JobSucceeded
+	}
+	j.log(ctx).Infof("Job %s %s", job.Id, job.Status)
+	j.running = nil
+	j.finished = append(j.finished, job)
+	if len(j.finished) > jobHistory {
+		j.finished = j.finished[len(j.finished)-jobHistory:]
+	}
+}
+
+func (j *JobService) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running != nil && j.running.Id == id {
+		job := *j.running
+		return &job, nil
+	}
+	for _, job := range j.finished {
+		if job.Id == id {
+			return &job, nil
+		}
+	}
+	return nil, MyErrors.DoesNotExist
+}
+
+// GetJobs returns the running job and the history of the finished ones, the latest first.
+func (j *JobService) GetJobs(ctx context.Context) ([]models.Job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jobs := make([]models.Job, 0, len(j.finished)+1)
+	if j.running != nil {
+		jobs = append(jobs, *j.running)
+	}
+	for i := len(j.finished) - 1; i >= 0; i-- {
+		jobs = append(jobs, j.finished[i])
+	}
+	return jobs, nil
+}
+
+// Shutdown waits for the running job. When ctx is done, the job is cancelled.
+func (j *JobService) Shutdown(ctx context.Context) error {
+	return j.jobs.stop(ctx)
+}
+
+func newJobId() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"tranee_service/MyErrors"
+	"tranee_service/internal/logging"
+	"tranee_service/models"
+)
+
+type loaderFunc func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error)
+
+// countryLoader is an AppCountries that only loads the images.
+type countryLoader struct {
+	AppCountries
+	load loaderFunc
+}
+
+func (c countryLoader) LoadImages(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+	return c.load(ctx, progress)
+}
+
+func newTestJobService(load loaderFunc) *JobService {
+	return NewJobService(countryLoader{load: load}, logging.GetLoggerLogrus())
+}
+
+// waitJob waits until the job is finished.
+func waitJob(t *testing.T, jobs *JobService, id string) *models.Job {
+	for i := 0; i < 100; i++ {
+		job, err := jobs.GetJob(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != models.JobRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s is still running", id)
+	return nil
+}
+
+func TestJobStatus(t *testing.T) {
+	testTable := []struct {
+		name           string
+		load           loaderFunc
+		shutdown       bool
+		expectedStatus string
+		expectedRun    models.LoadRun
+	}{
+		{
+			name: "Succeeded",
+			load: func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+				return models.LoadRun{Total: 2, Checked: 2, Found: 2}, nil
+			},
+			expectedStatus: models.JobSucceeded,
+			expectedRun:    models.LoadRun{Total: 2, Checked: 2, Found: 2},
+		},
+		{
+			name: "Failed",
+			load: func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+				return models.LoadRun{Error: "countries were not read"}, errors.New("connection refused")
+			},
+			expectedStatus: models.JobFailed,
+			expectedRun:    models.LoadRun{Error: "countries were not read"},
+		},
+		{
+			name: "Cancelled",
+			load: func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+				<-ctx.Done()
+				return models.LoadRun{Total: 2, Skipped: 2}, nil
+			},
+			shutdown:       true,
+			expectedStatus: models.JobCancelled,
+			expectedRun:    models.LoadRun{Total: 2, Skipped: 2},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			jobs := newTestJobService(testCase.load)
+
+			job, started, err := jobs.StartLoadImages(context.Background())
+			if testCase.shutdown {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				defer cancel()
+				assert.Equal(t, context.DeadlineExceeded, jobs.Shutdown(ctx))
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, started)
+			assert.Equal(t, models.JobLoadImages, job.Type)
+			finished := waitJob(t, jobs, job.Id)
+			assert.Equal(t, testCase.expectedStatus, finished.Status)
+			assert.Equal(t, testCase.expectedRun, finished.LoadRun)
+		})
+	}
+}
+
+func TestJobCoalescing(t *testing.T) {
+	release := make(chan struct{})
+	jobs := newTestJobService(func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+		progress(models.LoadRun{Total: 3, Checked: 1})
+		<-release
+		return models.LoadRun{Total: 3, Checked: 3}, nil
+	})
+
+	first, started, err := jobs.StartLoadImages(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, started)
+	second, started, err := jobs.StartLoadImages(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.Equal(t, first.Id, second.Id)
+
+	close(release)
+	waitJob(t, jobs, first.Id)
+	third, started, err := jobs.StartLoadImages(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, started)
+	assert.NotEqual(t, first.Id, third.Id)
+	waitJob(t, jobs, third.Id)
+}
+
+func TestJobHistory(t *testing.T) {
+	jobs := newTestJobService(func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+		return models.LoadRun{}, nil
+	})
+	var ids []string
+	for i := 0; i < jobHistory+2; i++ {
+		job, _, err := jobs.StartLoadImages(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitJob(t, jobs, job.Id)
+		ids = append(ids, job.Id)
+	}
+
+	list, err := jobs.GetJobs(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, list, jobHistory)
+	assert.Equal(t, ids[len(ids)-1], list[0].Id)
+	assert.Equal(t, ids[2], list[len(list)-1].Id)
+	_, err = jobs.GetJob(context.Background(), ids[0])
+	assert.Equal(t, MyErrors.DoesNotExist, err)
+}
+
+func TestJobAfterShutdown(t *testing.T) {
+	jobs := newTestJobService(func(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error) {
+		t.Error("job is started after shutdown")
+		return models.LoadRun{}, nil
+	})
+	assert.NoError(t, jobs.Shutdown(context.Background()))
+
+	job, started, err := jobs.StartLoadImages(context.Background())
+
+	assert.ErrorIs(t, err, MyErrors.ShuttingDown)
+	assert.False(t, started)
+	assert.Nil(t, job)
+	list, err := jobs.GetJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
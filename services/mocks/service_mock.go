@@ -5,6 +5,7 @@
 package mock_services
 
 import (
+	context "context"
 	reflect "reflect"
 	models "tranee_service/models"
 
@@ -35,89 +36,137 @@ func (m *MockAppCountries) EXPECT() *MockAppCountriesMockRecorder {
 }
 
 // ChangeCountry mocks base method.
-func (m *MockAppCountries) ChangeCountry(country *models.ResponseCountry, countryId string) error {
+func (m *MockAppCountries) ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ChangeCountry", country, countryId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ChangeCountry", ctx, country, countryId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ChangeCountry indicates an expected call of ChangeCountry.
-func (mr *MockAppCountriesMockRecorder) ChangeCountry(country, countryId interface{}) *gomock.Call {
+func (mr *MockAppCountriesMockRecorder) ChangeCountry(ctx, country, countryId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeCountry", reflect.TypeOf((*MockAppCountries)(nil).ChangeCountry), country, countryId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeCountry", reflect.TypeOf((*MockAppCountries)(nil).ChangeCountry), ctx, country, countryId)
 }
 
 // CreateCountry mocks base method.
-func (m *MockAppCountries) CreateCountry(country *models.ResponseCountry) (string, error) {
+func (m *MockAppCountries) CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateCountry", country)
+	ret := m.ctrl.Call(m, "CreateCountry", ctx, country)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateCountry indicates an expected call of CreateCountry.
-func (mr *MockAppCountriesMockRecorder) CreateCountry(country interface{}) *gomock.Call {
+func (mr *MockAppCountriesMockRecorder) CreateCountry(ctx, country interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCountry", reflect.TypeOf((*MockAppCountries)(nil).CreateCountry), country)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCountry", reflect.TypeOf((*MockAppCountries)(nil).CreateCountry), ctx, country)
 }
 
 // DeleteCountry mocks base method.
-func (m *MockAppCountries) DeleteCountry(countryId string) error {
+func (m *MockAppCountries) DeleteCountry(ctx context.Context, countryId string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCountry", countryId)
+	ret := m.ctrl.Call(m, "DeleteCountry", ctx, countryId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteCountry indicates an expected call of DeleteCountry.
-func (mr *MockAppCountriesMockRecorder) DeleteCountry(countryId interface{}) *gomock.Call {
+func (mr *MockAppCountriesMockRecorder) DeleteCountry(ctx, countryId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCountry", reflect.TypeOf((*MockAppCountries)(nil).DeleteCountry), countryId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCountry", reflect.TypeOf((*MockAppCountries)(nil).DeleteCountry), ctx, countryId)
 }
 
 // GetCountries mocks base method.
-func (m *MockAppCountries) GetCountries(filters *models.Filters) ([]models.Country, int, error) {
+func (m *MockAppCountries) GetCountries(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCountries", filters)
+	ret := m.ctrl.Call(m, "GetCountries", ctx, filters)
 	ret0, _ := ret[0].([]models.Country)
-	ret1, _ := ret[1].(int)
+	ret1, _ := ret[1].(models.PageInfo)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
 // GetCountries indicates an expected call of GetCountries.
-func (mr *MockAppCountriesMockRecorder) GetCountries(filters interface{}) *gomock.Call {
+func (mr *MockAppCountriesMockRecorder) GetCountries(ctx, filters interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCountries", reflect.TypeOf((*MockAppCountries)(nil).GetCountries), filters)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCountries", reflect.TypeOf((*MockAppCountries)(nil).GetCountries), ctx, filters)
+}
+
+// GetFlag mocks base method.
+func (m *MockAppCountries) GetFlag(ctx context.Context, id string, options models.FlagOptions) (*models.Flag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFlag", ctx, id, options)
+	ret0, _ := ret[0].(*models.Flag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFlag indicates an expected call of GetFlag.
+func (mr *MockAppCountriesMockRecorder) GetFlag(ctx, id, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFlag", reflect.TypeOf((*MockAppCountries)(nil).GetFlag), ctx, id, options)
 }
 
 // GetOneCountry mocks base method.
-func (m *MockAppCountries) GetOneCountry(id string) (*models.Country, error) {
+func (m *MockAppCountries) GetOneCountry(ctx context.Context, id string) (*models.Country, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOneCountry", id)
+	ret := m.ctrl.Call(m, "GetOneCountry", ctx, id)
 	ret0, _ := ret[0].(*models.Country)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOneCountry indicates an expected call of GetOneCountry.
-func (mr *MockAppCountriesMockRecorder) GetOneCountry(id interface{}) *gomock.Call {
+func (mr *MockAppCountriesMockRecorder) GetOneCountry(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneCountry", reflect.TypeOf((*MockAppCountries)(nil).GetOneCountry), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneCountry", reflect.TypeOf((*MockAppCountries)(nil).GetOneCountry), ctx, id)
 }
 
 // LoadImages mocks base method.
-func (m *MockAppCountries) LoadImages() {
+func (m *MockAppCountries) LoadImages(ctx context.Context, progress func(models.LoadRun)) (models.LoadRun, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "LoadImages")
+	ret := m.ctrl.Call(m, "LoadImages", ctx, progress)
+	ret0, _ := ret[0].(models.LoadRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // LoadImages indicates an expected call of LoadImages.
-func (mr *MockAppCountriesMockRecorder) LoadImages() *gomock.Call {
+func (mr *MockAppCountriesMockRecorder) LoadImages(ctx, progress interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadImages", reflect.TypeOf((*MockAppCountries)(nil).LoadImages))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadImages", reflect.TypeOf((*MockAppCountries)(nil).LoadImages), ctx, progress)
+}
+
+// PatchCountry mocks base method.
+func (m *MockAppCountries) PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchCountry", ctx, patch, countryId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchCountry indicates an expected call of PatchCountry.
+func (mr *MockAppCountriesMockRecorder) PatchCountry(ctx, patch, countryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchCountry", reflect.TypeOf((*MockAppCountries)(nil).PatchCountry), ctx, patch, countryId)
+}
+
+// SeedCountries mocks base method.
+func (m *MockAppCountries) SeedCountries(ctx context.Context, countries []models.Country) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SeedCountries", ctx, countries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SeedCountries indicates an expected call of SeedCountries.
+func (mr *MockAppCountriesMockRecorder) SeedCountries(ctx, countries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SeedCountries", reflect.TypeOf((*MockAppCountries)(nil).SeedCountries), ctx, countries)
 }
 
 // MockAppUsers is a mock of AppUsers interface.
@@ -143,93 +192,135 @@ func (m *MockAppUsers) EXPECT() *MockAppUsersMockRecorder {
 	return m.recorder
 }
 
+// AddUserHobby mocks base method.
+func (m *MockAppUsers) AddUserHobby(ctx context.Context, userId, hobbyId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUserHobby", ctx, userId, hobbyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUserHobby indicates an expected call of AddUserHobby.
+func (mr *MockAppUsersMockRecorder) AddUserHobby(ctx, userId, hobbyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserHobby", reflect.TypeOf((*MockAppUsers)(nil).AddUserHobby), ctx, userId, hobbyId)
+}
+
 // ChangeUser mocks base method.
-func (m *MockAppUsers) ChangeUser(user *models.User, userId int) error {
+func (m *MockAppUsers) ChangeUser(ctx context.Context, user *models.User, userId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ChangeUser", user, userId)
+	ret := m.ctrl.Call(m, "ChangeUser", ctx, user, userId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ChangeUser indicates an expected call of ChangeUser.
-func (mr *MockAppUsersMockRecorder) ChangeUser(user, userId interface{}) *gomock.Call {
+func (mr *MockAppUsersMockRecorder) ChangeUser(ctx, user, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUser", reflect.TypeOf((*MockAppUsers)(nil).ChangeUser), user, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUser", reflect.TypeOf((*MockAppUsers)(nil).ChangeUser), ctx, user, userId)
 }
 
 // CreateUser mocks base method.
-func (m *MockAppUsers) CreateUser(user *models.User) (int, error) {
+func (m *MockAppUsers) CreateUser(ctx context.Context, user *models.User) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockAppUsersMockRecorder) CreateUser(user interface{}) *gomock.Call {
+func (mr *MockAppUsersMockRecorder) CreateUser(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAppUsers)(nil).CreateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAppUsers)(nil).CreateUser), ctx, user)
 }
 
 // DeleteUser mocks base method.
-func (m *MockAppUsers) DeleteUser(userId int) error {
+func (m *MockAppUsers) DeleteUser(ctx context.Context, userId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", userId)
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockAppUsersMockRecorder) DeleteUser(userId interface{}) *gomock.Call {
+func (mr *MockAppUsersMockRecorder) DeleteUser(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockAppUsers)(nil).DeleteUser), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockAppUsers)(nil).DeleteUser), ctx, userId)
+}
+
+// DeleteUserHobby mocks base method.
+func (m *MockAppUsers) DeleteUserHobby(ctx context.Context, userId, hobbyId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserHobby", ctx, userId, hobbyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserHobby indicates an expected call of DeleteUserHobby.
+func (mr *MockAppUsersMockRecorder) DeleteUserHobby(ctx, userId, hobbyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserHobby", reflect.TypeOf((*MockAppUsers)(nil).DeleteUserHobby), ctx, userId, hobbyId)
 }
 
 // GetHobbyByUserId mocks base method.
-func (m *MockAppUsers) GetHobbyByUserId(userId int) ([]int, error) {
+func (m *MockAppUsers) GetHobbyByUserId(ctx context.Context, userId int) ([]int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetHobbyByUserId", userId)
+	ret := m.ctrl.Call(m, "GetHobbyByUserId", ctx, userId)
 	ret0, _ := ret[0].([]int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetHobbyByUserId indicates an expected call of GetHobbyByUserId.
-func (mr *MockAppUsersMockRecorder) GetHobbyByUserId(userId interface{}) *gomock.Call {
+func (mr *MockAppUsersMockRecorder) GetHobbyByUserId(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHobbyByUserId", reflect.TypeOf((*MockAppUsers)(nil).GetHobbyByUserId), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHobbyByUserId", reflect.TypeOf((*MockAppUsers)(nil).GetHobbyByUserId), ctx, userId)
 }
 
 // GetUserById mocks base method.
-func (m *MockAppUsers) GetUserById(userId int) (*models.ResponseUser, error) {
+func (m *MockAppUsers) GetUserById(ctx context.Context, userId int, expand models.Expand) (*models.ResponseUser, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", userId)
+	ret := m.ctrl.Call(m, "GetUserById", ctx, userId, expand)
 	ret0, _ := ret[0].(*models.ResponseUser)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockAppUsersMockRecorder) GetUserById(userId interface{}) *gomock.Call {
+func (mr *MockAppUsersMockRecorder) GetUserById(ctx, userId, expand interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockAppUsers)(nil).GetUserById), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockAppUsers)(nil).GetUserById), ctx, userId, expand)
 }
 
 // GetUsers mocks base method.
-func (m *MockAppUsers) GetUsers(filter *models.Options) ([]models.ResponseUser, int, error) {
+func (m *MockAppUsers) GetUsers(ctx context.Context, options *models.Options) ([]models.ResponseUser, models.PageInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", filter)
+	ret := m.ctrl.Call(m, "GetUsers", ctx, options)
 	ret0, _ := ret[0].([]models.ResponseUser)
-	ret1, _ := ret[1].(int)
+	ret1, _ := ret[1].(models.PageInfo)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockAppUsersMockRecorder) GetUsers(filter interface{}) *gomock.Call {
+func (mr *MockAppUsersMockRecorder) GetUsers(ctx, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAppUsers)(nil).GetUsers), ctx, options)
+}
+
+// PatchUser mocks base method.
+func (m *MockAppUsers) PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", ctx, patch, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockAppUsersMockRecorder) PatchUser(ctx, patch, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAppUsers)(nil).GetUsers), filter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockAppUsers)(nil).PatchUser), ctx, patch, userId)
 }
 
 // MockAppHobbies is a mock of AppHobbies interface.
@@ -255,32 +346,259 @@ func (m *MockAppHobbies) EXPECT() *MockAppHobbiesMockRecorder {
 	return m.recorder
 }
 
+// ChangeHobby mocks base method.
+func (m *MockAppHobbies) ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeHobby", ctx, hobby, hobbyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeHobby indicates an expected call of ChangeHobby.
+func (mr *MockAppHobbiesMockRecorder) ChangeHobby(ctx, hobby, hobbyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeHobby", reflect.TypeOf((*MockAppHobbies)(nil).ChangeHobby), ctx, hobby, hobbyId)
+}
+
 // CreateHobby mocks base method.
-func (m *MockAppHobbies) CreateHobby(hobby *models.Hobby) (int, error) {
+func (m *MockAppHobbies) CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateHobby", hobby)
+	ret := m.ctrl.Call(m, "CreateHobby", ctx, hobby)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateHobby indicates an expected call of CreateHobby.
-func (mr *MockAppHobbiesMockRecorder) CreateHobby(hobby interface{}) *gomock.Call {
+func (mr *MockAppHobbiesMockRecorder) CreateHobby(ctx, hobby interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHobby", reflect.TypeOf((*MockAppHobbies)(nil).CreateHobby), ctx, hobby)
+}
+
+// DeleteHobby mocks base method.
+func (m *MockAppHobbies) DeleteHobby(ctx context.Context, hobbyId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHobby", ctx, hobbyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHobby indicates an expected call of DeleteHobby.
+func (mr *MockAppHobbiesMockRecorder) DeleteHobby(ctx, hobbyId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHobby", reflect.TypeOf((*MockAppHobbies)(nil).CreateHobby), hobby)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHobby", reflect.TypeOf((*MockAppHobbies)(nil).DeleteHobby), ctx, hobbyId)
 }
 
 // GetHobbies mocks base method.
-func (m *MockAppHobbies) GetHobbies() ([]models.ResponseHobby, error) {
+func (m *MockAppHobbies) GetHobbies(ctx context.Context) ([]models.ResponseHobby, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetHobbies")
+	ret := m.ctrl.Call(m, "GetHobbies", ctx)
 	ret0, _ := ret[0].([]models.ResponseHobby)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetHobbies indicates an expected call of GetHobbies.
-func (mr *MockAppHobbiesMockRecorder) GetHobbies() *gomock.Call {
+func (mr *MockAppHobbiesMockRecorder) GetHobbies(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHobbies", reflect.TypeOf((*MockAppHobbies)(nil).GetHobbies), ctx)
+}
+
+// GetHobbyById mocks base method.
+func (m *MockAppHobbies) GetHobbyById(ctx context.Context, hobbyId int) (*models.ResponseHobby, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHobbyById", ctx, hobbyId)
+	ret0, _ := ret[0].(*models.ResponseHobby)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHobbyById indicates an expected call of GetHobbyById.
+func (mr *MockAppHobbiesMockRecorder) GetHobbyById(ctx, hobbyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHobbyById", reflect.TypeOf((*MockAppHobbies)(nil).GetHobbyById), ctx, hobbyId)
+}
+
+// GetUsersByHobbyId mocks base method.
+func (m *MockAppHobbies) GetUsersByHobbyId(ctx context.Context, hobbyId int) ([]models.ResponseUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByHobbyId", ctx, hobbyId)
+	ret0, _ := ret[0].([]models.ResponseUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByHobbyId indicates an expected call of GetUsersByHobbyId.
+func (mr *MockAppHobbiesMockRecorder) GetUsersByHobbyId(ctx, hobbyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByHobbyId", reflect.TypeOf((*MockAppHobbies)(nil).GetUsersByHobbyId), ctx, hobbyId)
+}
+
+// MockAppHealth is a mock of AppHealth interface.
+type MockAppHealth struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppHealthMockRecorder
+}
+
+// MockAppHealthMockRecorder is the mock recorder for MockAppHealth.
+type MockAppHealthMockRecorder struct {
+	mock *MockAppHealth
+}
+
+// NewMockAppHealth creates a new mock instance.
+func NewMockAppHealth(ctrl *gomock.Controller) *MockAppHealth {
+	mock := &MockAppHealth{ctrl: ctrl}
+	mock.recorder = &MockAppHealthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAppHealth) EXPECT() *MockAppHealthMockRecorder {
+	return m.recorder
+}
+
+// Live mocks base method.
+func (m *MockAppHealth) Live(ctx context.Context) models.Health {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Live", ctx)
+	ret0, _ := ret[0].(models.Health)
+	return ret0
+}
+
+// Live indicates an expected call of Live.
+func (mr *MockAppHealthMockRecorder) Live(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Live", reflect.TypeOf((*MockAppHealth)(nil).Live), ctx)
+}
+
+// Ready mocks base method.
+func (m *MockAppHealth) Ready(ctx context.Context) models.Health {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready", ctx)
+	ret0, _ := ret[0].(models.Health)
+	return ret0
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockAppHealthMockRecorder) Ready(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockAppHealth)(nil).Ready), ctx)
+}
+
+// MockAppJobs is a mock of AppJobs interface.
+type MockAppJobs struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppJobsMockRecorder
+}
+
+// MockAppJobsMockRecorder is the mock recorder for MockAppJobs.
+type MockAppJobsMockRecorder struct {
+	mock *MockAppJobs
+}
+
+// NewMockAppJobs creates a new mock instance.
+func NewMockAppJobs(ctrl *gomock.Controller) *MockAppJobs {
+	mock := &MockAppJobs{ctrl: ctrl}
+	mock.recorder = &MockAppJobsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAppJobs) EXPECT() *MockAppJobsMockRecorder {
+	return m.recorder
+}
+
+// GetJob mocks base method.
+func (m *MockAppJobs) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockAppJobsMockRecorder) GetJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockAppJobs)(nil).GetJob), ctx, id)
+}
+
+// GetJobs mocks base method.
+func (m *MockAppJobs) GetJobs(ctx context.Context) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJobs", ctx)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJobs indicates an expected call of GetJobs.
+func (mr *MockAppJobsMockRecorder) GetJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobs", reflect.TypeOf((*MockAppJobs)(nil).GetJobs), ctx)
+}
+
+// Shutdown mocks base method.
+func (m *MockAppJobs) Shutdown(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shutdown", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockAppJobsMockRecorder) Shutdown(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockAppJobs)(nil).Shutdown), ctx)
+}
+
+// StartLoadImages mocks base method.
+func (m *MockAppJobs) StartLoadImages(ctx context.Context) (*models.Job, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartLoadImages", ctx)
+	ret0, _ := ret[0].(*models.Job)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartLoadImages indicates an expected call of StartLoadImages.
+func (mr *MockAppJobsMockRecorder) StartLoadImages(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartLoadImages", reflect.TypeOf((*MockAppJobs)(nil).StartLoadImages), ctx)
+}
+
+// MockLoadObserver is a mock of LoadObserver interface.
+type MockLoadObserver struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoadObserverMockRecorder
+}
+
+// MockLoadObserverMockRecorder is the mock recorder for MockLoadObserver.
+type MockLoadObserverMockRecorder struct {
+	mock *MockLoadObserver
+}
+
+// NewMockLoadObserver creates a new mock instance.
+func NewMockLoadObserver(ctrl *gomock.Controller) *MockLoadObserver {
+	mock := &MockLoadObserver{ctrl: ctrl}
+	mock.recorder = &MockLoadObserverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoadObserver) EXPECT() *MockLoadObserverMockRecorder {
+	return m.recorder
+}
+
+// ObserveLoad mocks base method.
+func (m *MockLoadObserver) ObserveLoad(run models.LoadRun) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveLoad", run)
+}
+
+// ObserveLoad indicates an expected call of ObserveLoad.
+func (mr *MockLoadObserverMockRecorder) ObserveLoad(run interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHobbies", reflect.TypeOf((*MockAppHobbies)(nil).GetHobbies))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveLoad", reflect.TypeOf((*MockLoadObserver)(nil).ObserveLoad), run)
 }
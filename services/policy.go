@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"tranee_service/MyErrors"
+	"tranee_service/models"
+)
+
+// RoleAdmin is the role of the callers that can manage every resource.
+const RoleAdmin = "admin"
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the caller the policy checks.
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller carried by ctx, or nil for an anonymous request.
+func PrincipalFromContext(ctx context.Context) *models.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*models.Principal)
+	return principal
+}
+
+// Authorize wraps the services with the policy: countries and hobbies are managed by admins, a user record and
+// its hobbies are changed by its owner or by an admin, jobs are started by admins. Reads are not checked.
+func Authorize(service *Service) *Service {
+	return &Service{
+		AppCountries: &countryPolicy{service.AppCountries},
+		AppUsers:     &userPolicy{service.AppUsers},
+		AppHobbies:   &hobbyPolicy{service.AppHobbies},
+		AppHealth:    service.AppHealth,
+		AppJobs:      &jobPolicy{service.AppJobs},
+	}
+}
+
+func isAdmin(principal *models.Principal) bool {
+	for _, role := range principal.Roles {
+		if role == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func requireAdmin(ctx context.Context) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return MyErrors.New(MyErrors.Unauthorized, "credentials are required")
+	}
+	if !isAdmin(principal) {
+		return &MyErrors.ForbiddenError{Reason: MyErrors.AdminRequired}
+	}
+	return nil
+}
+
+func requireOwner(ctx context.Context, userId int) error {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return MyErrors.New(MyErrors.Unauthorized, "credentials are required")
+	}
+	if !isAdmin(principal) && (principal.UserId == 0 || principal.UserId != userId) {
+		return &MyErrors.ForbiddenError{Reason: MyErrors.NotOwner}
+	}
+	return nil
+}
+
+type countryPolicy struct {
+	AppCountries
+}
+
+func (c *countryPolicy) CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	return c.AppCountries.CreateCountry(ctx, country)
+}
+
+func (c *countryPolicy) ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	return c.AppCountries.ChangeCountry(ctx, country, countryId)
+}
+
+func (c *countryPolicy) PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	return c.AppCountries.PatchCountry(ctx, patch, countryId)
+}
+
+func (c *countryPolicy) DeleteCountry(ctx context.Context, countryId string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return c.AppCountries.DeleteCountry(ctx, countryId)
+}
+
+type userPolicy struct {
+	AppUsers
+}
+
+func (u *userPolicy) ChangeUser(ctx context.Context, user *models.User, userId int) error {
+	if err := requireOwner(ctx, userId); err != nil {
+		return err
+	}
+	return u.AppUsers.ChangeUser(ctx, user, userId)
+}
+
+func (u *userPolicy) PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error {
+	if err := requireOwner(ctx, userId); err != nil {
+		return err
+	}
+	return u.AppUsers.PatchUser(ctx, patch, userId)
+}
+
+func (u *userPolicy) DeleteUser(ctx context.Context, userId int) error {
+	if err := requireOwner(ctx, userId); err != nil {
+		return err
+	}
+	return u.AppUsers.DeleteUser(ctx, userId)
+}
+
+func (u *userPolicy) AddUserHobby(ctx context.Context, userId, hobbyId int) error {
+	if err := requireOwner(ctx, userId); err != nil {
+		return err
+	}
+	return u.AppUsers.AddUserHobby(ctx, userId, hobbyId)
+}
+
+func (u *userPolicy) DeleteUserHobby(ctx context.Context, userId, hobbyId int) error {
+	if err := requireOwner(ctx, userId); err != nil {
+		return err
+	}
+	return u.AppUsers.DeleteUserHobby(ctx, userId, hobbyId)
+}
+
+type hobbyPolicy struct {
+	AppHobbies
+}
+
+func (h *hobbyPolicy) CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+	return h.AppHobbies.CreateHobby(ctx, hobby)
+}
+
+func (h *hobbyPolicy) ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return h.AppHobbies.ChangeHobby(ctx, hobby, hobbyId)
+}
+
+func (h *hobbyPolicy) DeleteHobby(ctx context.Context, hobbyId int) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return h.AppHobbies.DeleteHobby(ctx, hobbyId)
+}
+
+type jobPolicy struct {
+	AppJobs
+}
+
+func (j *jobPolicy) StartLoadImages(ctx context.Context) (*models.Job, bool, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, false, err
+	}
+	return j.AppJobs.StartLoadImages(ctx)
+}
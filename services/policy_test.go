@@ -0,0 +1,151 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"tranee_service/MyErrors"
+	"tranee_service/models"
+	"tranee_service/services"
+	mockservice "tranee_service/services/mocks"
+)
+
+func TestAuthorize(t *testing.T) {
+	type mockBehavior func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies)
+	admin := &models.Principal{Subject: "admin", Roles: []string{services.RoleAdmin}}
+	owner := &models.Principal{Subject: "7", UserId: 7}
+	stranger := &models.Principal{Subject: "8", UserId: 8}
+
+	testTable := []struct {
+		name           string
+		call           func(s *services.Service) error
+		mockBehavior   mockBehavior
+		expectedReason MyErrors.Reason
+		expectedError  error
+	}{
+		{
+			name: "Admin deletes country",
+			call: func(s *services.Service) error {
+				return s.DeleteCountry(services.WithPrincipal(context.Background(), admin), "RU")
+			},
+			mockBehavior: func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {
+				c.EXPECT().DeleteCountry(gomock.Any(), "RU").Return(nil)
+			},
+		},
+		{
+			name: "User changes country",
+			call: func(s *services.Service) error {
+				_, err := s.ChangeCountry(services.WithPrincipal(context.Background(), owner), &models.ResponseCountry{}, "RU")
+				return err
+			},
+			mockBehavior:   func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedReason: MyErrors.AdminRequired,
+		},
+		{
+			name: "User creates hobby",
+			call: func(s *services.Service) error {
+				_, err := s.CreateHobby(services.WithPrincipal(context.Background(), owner), &models.Hobby{Name: "chess"})
+				return err
+			},
+			mockBehavior:   func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedReason: MyErrors.AdminRequired,
+		},
+		{
+			name: "User starts loading of images",
+			call: func(s *services.Service) error {
+				_, _, err := s.StartLoadImages(services.WithPrincipal(context.Background(), owner))
+				return err
+			},
+			mockBehavior:   func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedReason: MyErrors.AdminRequired,
+		},
+		{
+			name: "Owner changes user",
+			call: func(s *services.Service) error {
+				return s.ChangeUser(services.WithPrincipal(context.Background(), owner), &models.User{}, 7)
+			},
+			mockBehavior: func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {
+				u.EXPECT().ChangeUser(gomock.Any(), &models.User{}, 7).Return(nil)
+			},
+		},
+		{
+			name: "Admin deletes user",
+			call: func(s *services.Service) error {
+				return s.DeleteUser(services.WithPrincipal(context.Background(), admin), 7)
+			},
+			mockBehavior: func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {
+				u.EXPECT().DeleteUser(gomock.Any(), 7).Return(nil)
+			},
+		},
+		{
+			name: "Stranger deletes user",
+			call: func(s *services.Service) error {
+				return s.DeleteUser(services.WithPrincipal(context.Background(), stranger), 7)
+			},
+			mockBehavior:   func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedReason: MyErrors.NotOwner,
+		},
+		{
+			name: "Stranger adds hobby to user",
+			call: func(s *services.Service) error {
+				return s.AddUserHobby(services.WithPrincipal(context.Background(), stranger), 7, 1)
+			},
+			mockBehavior:   func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedReason: MyErrors.NotOwner,
+		},
+		{
+			name: "Caller without user record deletes user",
+			call: func(s *services.Service) error {
+				return s.DeleteUser(services.WithPrincipal(context.Background(), &models.Principal{Subject: "ci"}), 7)
+			},
+			mockBehavior:   func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedReason: MyErrors.NotOwner,
+		},
+		{
+			name: "Anonymous deletes user",
+			call: func(s *services.Service) error {
+				return s.DeleteUser(context.Background(), 7)
+			},
+			mockBehavior:  func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {},
+			expectedError: MyErrors.New(MyErrors.Unauthorized, "credentials are required"),
+		},
+		{
+			name: "Anonymous reads user",
+			call: func(s *services.Service) error {
+				_, err := s.GetUserById(context.Background(), 7, models.Expand{})
+				return err
+			},
+			mockBehavior: func(c *mockservice.MockAppCountries, u *mockservice.MockAppUsers, h *mockservice.MockAppHobbies) {
+				u.EXPECT().GetUserById(gomock.Any(), 7, models.Expand{}).Return(&models.ResponseUser{Id: 7}, nil)
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			countries := mockservice.NewMockAppCountries(c)
+			users := mockservice.NewMockAppUsers(c)
+			hobbies := mockservice.NewMockAppHobbies(c)
+			testCase.mockBehavior(countries, users, hobbies)
+			service := services.Authorize(&services.Service{AppCountries: countries, AppUsers: users, AppHobbies: hobbies})
+
+			err := testCase.call(service)
+
+			var forbidden *MyErrors.ForbiddenError
+			switch {
+			case testCase.expectedReason != "":
+				if assert.True(t, errors.As(err, &forbidden)) {
+					assert.Equal(t, testCase.expectedReason, forbidden.Reason)
+				}
+			case testCase.expectedError != nil:
+				assert.Equal(t, testCase.expectedError, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"tranee_service/internal/blobstore"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 	"tranee_service/repositories"
@@ -9,38 +11,72 @@ import (
 //go:generate mockgen -source=service.go -destination=mocks/service_mock.go
 
 type AppCountries interface {
-	GetOneCountry(id string) (*models.Country, error)
-	GetCountries(filters *models.Filters) ([]models.Country, int, error)
-	CreateCountry(country *models.ResponseCountry) (string, error)
-	ChangeCountry(country *models.ResponseCountry, countryId string) error
-	DeleteCountry(countryId string) error
-	LoadImages()
+	GetOneCountry(ctx context.Context, id string) (*models.Country, error)
+	GetCountries(ctx context.Context, filters *models.Filters) ([]models.Country, models.PageInfo, error)
+	CreateCountry(ctx context.Context, country *models.ResponseCountry) (string, error)
+	ChangeCountry(ctx context.Context, country *models.ResponseCountry, countryId string) (string, error)
+	PatchCountry(ctx context.Context, patch *models.CountryPatch, countryId string) (string, error)
+	DeleteCountry(ctx context.Context, countryId string) error
+	GetFlag(ctx context.Context, id string, options models.FlagOptions) (*models.Flag, error)
+	LoadImages(ctx context.Context, progress func(run models.LoadRun)) (models.LoadRun, error)
+	SeedCountries(ctx context.Context, countries []models.Country) error
 }
 
 type AppUsers interface {
-	CreateUser(user *models.User) (int, error)
-	GetUserById(userId int) (*models.ResponseUser, error)
-	GetUsers(options *models.Options) ([]models.ResponseUser, int, error)
-	ChangeUser(user *models.User, userId int) error
-	DeleteUser(userId int) error
-	GetHobbyByUserId(userId int) ([]int, error)
+	CreateUser(ctx context.Context, user *models.User) (int, error)
+	GetUserById(ctx context.Context, userId int, expand models.Expand) (*models.ResponseUser, error)
+	GetUsers(ctx context.Context, options *models.Options) ([]models.ResponseUser, models.PageInfo, error)
+	ChangeUser(ctx context.Context, user *models.User, userId int) error
+	PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error
+	DeleteUser(ctx context.Context, userId int) error
+	GetHobbyByUserId(ctx context.Context, userId int) ([]int, error)
+	AddUserHobby(ctx context.Context, userId, hobbyId int) error
+	DeleteUserHobby(ctx context.Context, userId, hobbyId int) error
 }
 
 type AppHobbies interface {
-	CreateHobby(hobby *models.Hobby) (int, error)
-	GetHobbies() ([]models.ResponseHobby, error)
+	CreateHobby(ctx context.Context, hobby *models.Hobby) (int, error)
+	GetHobbies(ctx context.Context) ([]models.ResponseHobby, error)
+	GetHobbyById(ctx context.Context, hobbyId int) (*models.ResponseHobby, error)
+	ChangeHobby(ctx context.Context, hobby *models.Hobby, hobbyId int) error
+	DeleteHobby(ctx context.Context, hobbyId int) error
+	GetUsersByHobbyId(ctx context.Context, hobbyId int) ([]models.ResponseUser, error)
+}
+
+type AppHealth interface {
+	Live(ctx context.Context) models.Health
+	Ready(ctx context.Context) models.Health
+}
+
+type AppJobs interface {
+	StartLoadImages(ctx context.Context) (*models.Job, bool, error)
+	GetJob(ctx context.Context, id string) (*models.Job, error)
+	GetJobs(ctx context.Context) ([]models.Job, error)
+	Shutdown(ctx context.Context) error
+}
+
+// LoadObserver counts the results of the loading of the images.
+type LoadObserver interface {
+	ObserveLoad(run models.LoadRun)
 }
 
 type Service struct {
 	AppCountries
 	AppUsers
 	AppHobbies
+	AppHealth
+	AppJobs
 }
 
-func NewService(repository *repositories.Repository, logger logging.Logger) *Service {
+func NewService(repository *repositories.Repository, logger logging.Logger, observer LoadObserver, provider FlagProvider,
+	config FetchConfig, store blobstore.Store) *Service {
+	state := &State{}
+	countries := NewCountryService(repository, logger, state, observer, provider, config, store)
 	return &Service{
-		AppCountries: NewCountryService(repository, logger),
+		AppCountries: countries,
 		AppUsers:     NewUserService(repository, logger),
 		AppHobbies:   NewHobbyService(repository, logger),
+		AppHealth:    NewHealthService(repository, logger, state),
+		AppJobs:      NewJobService(countries, logger),
 	}
 }
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"sync"
+	"tranee_service/models"
+)
+
+// State keeps the results of the background work of the service for the readiness check.
+type State struct {
+	mu       sync.Mutex
+	seeded   bool
+	seedErr  error
+	lastLoad *models.LoadRun
+}
+
+func (s *State) seedFinished(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seeded = err == nil
+	s.seedErr = err
+}
+
+// setLastLoad stores a copy of the run, so that the loader can go on changing its own.
+func (s *State) setLastLoad(run models.LoadRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastLoad = &run
+}
+
+func (s *State) seedCheck() models.Check {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.seedErr != nil:
+		return models.Check{Status: models.StatusFail, Detail: "initial countries were not saved"}
+	case !s.seeded:
+		return models.Check{Status: models.StatusFail, Detail: "initial countries are being saved"}
+	}
+	return models.Check{Status: models.StatusPass}
+}
+
+// loaderCheck reports the last loading of the images. The service can serve requests without the images,
+// so a failed loading is only a warning.
+func (s *State) loaderCheck() models.Check {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastLoad == nil {
+		return models.Check{Status: models.StatusPass, Detail: "images have not been loaded yet"}
+	}
+	run := *s.lastLoad
+	run.Report = nil
+	check := models.Check{Status: models.StatusPass, LastRun: &run}
+	if run.Error != "" {
+		check.Status = models.StatusWarn
+	}
+	return check
+}
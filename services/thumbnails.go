@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"tranee_service/internal/blobstore"
+)
+
+// ThumbnailWidths are the widths the thumbnails of the flags are made in, other widths would fill the store.
+var ThumbnailWidths = []int{16, 32, 64, 128, 256, 512}
+
+// maxFlagPixels limits the images that are decoded, a small file can declare a huge image.
+const maxFlagPixels = 40 << 20
+
+// errNotResizable is returned for the images that can not be decoded, their originals are served instead.
+var errNotResizable = errors.New("image can not be resized")
+
+// thumbnailKey is the key of the thumbnail of the flag of the country in the store.
+func thumbnailKey(alpha2 string, width int) string {
+	return "thumbnails/" + alpha2 + "/" + strconv.Itoa(width)
+}
+
+// thumbnailer makes the thumbnails of the flags on demand and keeps them in the store. The source of a kept
+// thumbnail is the hash of its original, so a changed original makes the thumbnail again.
+type thumbnailer struct {
+	store blobstore.Store
+}
+
+// open returns the thumbnail of the flag of the given width, making it if it is not kept or is outdated.
+func (t *thumbnailer) open(ctx context.Context, alpha2 string, width int, original blobstore.Info,
+	content io.Reader) (io.ReadSeekCloser, blobstore.Info, error) {
+	key := thumbnailKey(alpha2, width)
+	thumbnail, info, err := t.store.Open(ctx, key)
+	if err == nil && info.Source == original.Hash {
+		return thumbnail, info, nil
+	}
+	if err == nil {
+		thumbnail.Close()
+	} else if !errors.Is(err, blobstore.ErrNotFound) {
+		return nil, blobstore.Info{}, err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, blobstore.Info{}, err
+	}
+	resized, contentType, err := resize(data, original.ContentType, width)
+	if err != nil {
+		return nil, blobstore.Info{}, err
+	}
+	if _, err := t.store.Put(ctx, key, resized, blobstore.Info{ContentType: contentType, Source: original.Hash}); err != nil {
+		return nil, blobstore.Info{}, err
+	}
+	return t.store.Open(ctx, key)
+}
+
+// resize makes the image of the given width keeping its proportions. JPEG stays JPEG, the rest becomes PNG.
+// The images narrower than the width are not enlarged.
+func resize(data []byte, contentType string, width int) ([]byte, string, error) {
+	var src image.Image
+	var err error
+	if contentType == "image/svg+xml" {
+		src, err = rasterize(data, width)
+	} else {
+		src, err = decode(data)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	bounds := src.Bounds()
+	if bounds.Dx() > width {
+		height := bounds.Dy() * width / bounds.Dx()
+		if height < 1 {
+			height = 1
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+		src = dst
+	}
+	var buffer bytes.Buffer
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buffer, src, &jpeg.Options{Quality: 85})
+	} else {
+		contentType = "image/png"
+		err = png.Encode(&buffer, src)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("resize: error while encoding image:%w", err)
+	}
+	return buffer.Bytes(), contentType, nil
+}
+
+func decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errNotResizable, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxFlagPixels {
+		return nil, fmt.Errorf("%w: image of %dx%d pixels", errNotResizable, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errNotResizable, err)
+	}
+	return img, nil
+}
+
+// rasterize draws the SVG image at the given width.
+func rasterize(data []byte, width int) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errNotResizable, err)
+	}
+	if icon.ViewBox.W <= 0 || icon.ViewBox.H <= 0 {
+		return nil, fmt.Errorf("%w: SVG image has no size", errNotResizable)
+	}
+	height := int(float64(width) * icon.ViewBox.H / icon.ViewBox.W)
+	if height < 1 || width*height > maxFlagPixels {
+		return nil, fmt.Errorf("%w: SVG image of %dx%d pixels", errNotResizable, width, height)
+	}
+	icon.SetTarget(0, 0, float64(width), float64(height))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+	return img, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+	"tranee_service/internal/blobstore"
+)
+
+func encodeImage(t *testing.T, width, height int, encode func(w io.Writer, img image.Image) error) []byte {
+	var buffer bytes.Buffer
+	if err := encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestResize(t *testing.T) {
+	pngImage := encodeImage(t, 200, 100, png.Encode)
+	jpegImage := encodeImage(t, 300, 200, func(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, nil) })
+
+	testTable := []struct {
+		name                string
+		data                []byte
+		contentType         string
+		width               int
+		expectedContentType string
+		expectedFormat      string
+		expectedSize        image.Point
+		expectedError       error
+	}{
+		{
+			name:                "PNG",
+			data:                pngImage,
+			contentType:         "image/png",
+			width:               64,
+			expectedContentType: "image/png",
+			expectedFormat:      "png",
+			expectedSize:        image.Pt(64, 32),
+		},
+		{
+			name:                "JPEG stays JPEG",
+			data:                jpegImage,
+			contentType:         "image/jpeg",
+			width:               128,
+			expectedContentType: "image/jpeg",
+			expectedFormat:      "jpeg",
+			expectedSize:        image.Pt(128, 85),
+		},
+		{
+			name:                "Narrow image is not enlarged",
+			data:                pngImage,
+			contentType:         "image/png",
+			width:               256,
+			expectedContentType: "image/png",
+			expectedFormat:      "png",
+			expectedSize:        image.Pt(200, 100),
+		},
+		{
+			name:                "SVG with view box",
+			data:                []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 30 20"><rect width="30" height="20" fill="red"/></svg>`),
+			contentType:         "image/svg+xml",
+			width:               64,
+			expectedContentType: "image/png",
+			expectedFormat:      "png",
+			expectedSize:        image.Pt(64, 42),
+		},
+		{
+			name:                "SVG with size",
+			data:                []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="900" height="600"><rect width="900" height="600" fill="red"/></svg>`),
+			contentType:         "image/svg+xml",
+			width:               32,
+			expectedContentType: "image/png",
+			expectedFormat:      "png",
+			expectedSize:        image.Pt(32, 21),
+		},
+		{
+			name:          "Unknown format",
+			data:          []byte("not an image"),
+			contentType:   "image/x-icon",
+			width:         64,
+			expectedError: errNotResizable,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			data, contentType, err := resize(testCase.data, testCase.contentType, testCase.width)
+
+			if testCase.expectedError != nil {
+				assert.True(t, errors.Is(err, testCase.expectedError), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedContentType, contentType)
+			config, format, err := image.DecodeConfig(bytes.NewReader(data))
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.expectedFormat, format)
+				assert.Equal(t, testCase.expectedSize, image.Pt(config.Width, config.Height))
+			}
+		})
+	}
+}
+
+func TestThumbnailer(t *testing.T) {
+	store, err := blobstore.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbnails := &thumbnailer{store: store}
+	ctx := context.Background()
+	original := encodeImage(t, 200, 100, png.Encode)
+	info := blobstore.Info{ContentType: "image/png", Hash: blobstore.Hash(original)}
+
+	content, first, err := thumbnails.open(ctx, "RU", 64, info, bytes.NewReader(original))
+	if assert.NoError(t, err) {
+		content.Close()
+	}
+	assert.Equal(t, info.Hash, first.Source)
+
+	content, second, err := thumbnails.open(ctx, "RU", 64, info, bytes.NewReader(nil))
+	if assert.NoError(t, err) {
+		content.Close()
+	}
+	assert.Equal(t, first, second, "the kept thumbnail is served")
+
+	changed := encodeImage(t, 300, 100, png.Encode)
+	changedInfo := blobstore.Info{ContentType: "image/png", Hash: blobstore.Hash(changed)}
+	content, third, err := thumbnails.open(ctx, "RU", 64, changedInfo, bytes.NewReader(changed))
+	if assert.NoError(t, err) {
+		defer content.Close()
+		config, err := png.DecodeConfig(content)
+		assert.NoError(t, err)
+		assert.Equal(t, 21, config.Height)
+	}
+	assert.Equal(t, changedInfo.Hash, third.Source)
+}
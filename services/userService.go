@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"tranee_service/internal/logging"
 	"tranee_service/models"
 	"tranee_service/repositories"
@@ -15,25 +16,38 @@ func NewUserService(repository *repositories.Repository, logger logging.Logger)
 	return &UserService{repository: repository, logger: logger}
 }
 
-func (u *UserService) CreateUser(user *models.User) (int, error) {
-	return u.repository.AppUsers.CreateUser(user)
+func (u *UserService) CreateUser(ctx context.Context, user *models.User) (int, error) {
+	return u.repository.AppUsers.CreateUser(ctx, user)
 }
 
-func (u *UserService) GetUserById(userId int) (*models.ResponseUser, error) {
-	return u.repository.AppUsers.GetUserById(userId)
+func (u *UserService) GetUserById(ctx context.Context, userId int, expand models.Expand) (*models.ResponseUser, error) {
+	return u.repository.AppUsers.GetUserById(ctx, userId, expand)
 }
 
-func (u *UserService) GetUsers(options *models.Options) ([]models.ResponseUser, int, error) {
-	return u.repository.AppUsers.GetUsers(options)
+func (u *UserService) GetUsers(ctx context.Context, options *models.Options) ([]models.ResponseUser, models.PageInfo, error) {
+	return u.repository.AppUsers.GetUsers(ctx, options)
 }
 
-func (u *UserService) ChangeUser(user *models.User, userId int) error {
-	return u.repository.AppUsers.ChangeUser(user, userId)
+func (u *UserService) ChangeUser(ctx context.Context, user *models.User, userId int) error {
+	return u.repository.AppUsers.ChangeUser(ctx, user, userId)
 }
 
-func (u *UserService) DeleteUser(userId int) error {
-	return u.repository.AppUsers.DeleteUser(userId)
+func (u *UserService) PatchUser(ctx context.Context, patch *models.UserPatch, userId int) error {
+	return u.repository.AppUsers.PatchUser(ctx, patch, userId)
 }
-func (u *UserService) GetHobbyByUserId(userId int) ([]int, error) {
-	return u.repository.AppHobbies.GetHobbyByUserId(userId)
+
+func (u *UserService) DeleteUser(ctx context.Context, userId int) error {
+	return u.repository.AppUsers.DeleteUser(ctx, userId)
+}
+
+func (u *UserService) GetHobbyByUserId(ctx context.Context, userId int) ([]int, error) {
+	return u.repository.AppHobbies.GetHobbyByUserId(ctx, userId)
+}
+
+func (u *UserService) AddUserHobby(ctx context.Context, userId, hobbyId int) error {
+	return u.repository.AppHobbies.AddUserHobby(ctx, userId, hobbyId)
+}
+
+func (u *UserService) DeleteUserHobby(ctx context.Context, userId, hobbyId int) error {
+	return u.repository.AppHobbies.DeleteUserHobby(ctx, userId, hobbyId)
 }